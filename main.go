@@ -15,10 +15,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/albrow/prtty"
 	"github.com/go-humble/temple/temple"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
@@ -45,33 +47,184 @@ func setVerbose() {
 	prtty.Error.Output = os.Stderr
 }
 
+// addBuildFlags adds the flags which control how templates are
+// built to cmd. They are shared by the build, watch, and check commands.
+func addBuildFlags(cmd *cobra.Command) {
+	cmd.Flags().String("partials", "", "(optional) The directory to look for partials. Partials are .tmpl files that are associated with layouts and all other templates.")
+	cmd.Flags().String("layouts", "", "(optional) The directory to look for layouts. Layouts are .tmpl files which have access to partials and are associated with all other templates.")
+	cmd.Flags().String("package", "", "(optional) The package name for the generated go file. If not provided, the default will be the directory where the go file is created.")
+	cmd.Flags().String("funcs", "", "(optional) An exported template.FuncMap variable in an importable package, e.g. github.com/me/app/helpers.FuncMap. The functions will be available to all templates.")
+	cmd.Flags().Bool("precompile", false, "(optional) If set to true, the generated code will hold parse trees instead of template source, so no templates are parsed at runtime.")
+	cmd.Flags().Bool("lazy", false, "(optional) If set to true, the generated code will parse each template the first time it is needed instead of when the package is initialized.")
+	cmd.Flags().String("mode", "html", "(optional) Either html or text. In text mode, templates are compiled with text/template instead of html/template, so nothing is escaped.")
+	cmd.Flags().String("extensions", "", "(optional) A comma-separated list of file extensions for templates, partials, and layouts, e.g. .tmpl,.html,.gohtml. The default is .tmpl.")
+	cmd.Flags().String("ignore", "", "(optional) A comma-separated list of patterns for files and directories to ignore, e.g. *.swp,node_modules. Patterns can also be listed in a .templeignore file in each directory.")
+	cmd.Flags().Bool("strict", false, "(optional) If set to true, warnings such as partials and layouts which are not used by any template cause the command to fail.")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "If set to true, temple will print out information while building.")
+}
+
+// buildOptions returns the temple.BuildOptions which correspond to
+// the optional flags that were set for cmd.
+func buildOptions(cmd *cobra.Command) []temple.BuildOption {
+	options := []temple.BuildOption{}
+	if funcs := cmd.Flag("funcs").Value.String(); funcs != "" {
+		options = append(options, temple.WithFuncs(funcs))
+	}
+	if cmd.Flag("precompile").Value.String() == "true" {
+		options = append(options, temple.WithPrecompile())
+	}
+	if cmd.Flag("lazy").Value.String() == "true" {
+		options = append(options, temple.WithLazy())
+	}
+	if mode := cmd.Flag("mode").Value.String(); mode != "html" {
+		options = append(options, temple.WithMode(mode))
+	}
+	if extensions := cmd.Flag("extensions").Value.String(); extensions != "" {
+		options = append(options, temple.WithExtensions(splitList(extensions)...))
+	}
+	if ignore := cmd.Flag("ignore").Value.String(); ignore != "" {
+		options = append(options, temple.WithIgnore(splitList(ignore)...))
+	}
+	if cmd.Flag("strict").Value.String() == "true" {
+		options = append(options, temple.WithStrict())
+	}
+	return options
+}
+
+// splitList splits a comma-separated flag value into its elements,
+// trimming whitespace from each one and skipping any which are empty, so
+// that e.g. ".tmpl, .html," is the same as ".tmpl,.html".
+func splitList(list string) []string {
+	elements := []string{}
+	for _, element := range strings.Split(list, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// configFlags holds the flags which can be used when building the targets
+// in a config file. Every other build flag corresponds to a field of the
+// targets, and is rejected by checkConfigFlags instead of being ignored.
+var configFlags = map[string]bool{
+	"config":  true,
+	"strict":  true,
+	"verbose": true,
+}
+
+// checkConfigFlags returns an error if any build flags which don't apply
+// when building from a config file were set for cmd.
+func checkConfigFlags(cmd *cobra.Command) error {
+	names := []string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !configFlags[flag.Name] {
+			names = append(names, "--"+flag.Name)
+		}
+	})
+	if len(names) == 0 {
+		return nil
+	}
+	return fmt.Errorf("temple: %s cannot be used when building from a config file. Set the corresponding fields of the targets in the config file instead.", strings.Join(names, ", "))
+}
+
 func main() {
 	// Define build command
 	cmdBuild := &cobra.Command{
-		Use:   "build <src> <dest>",
+		Use:   "build [<src> <dest>]",
 		Short: "Compile the templates in the src directory and write generated go code to the dest file.",
+		Long: `
+Compile the templates in the src directory and write generated go code to the dest file.
+If no arguments are given, every target declared in the config file is built instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 && len(args) != 2 {
+				prtty.Error.Fatal("temple build requires either 0 arguments or 2 arguments: the src directory and the dest file.")
+			}
+			if verbose {
+				setVerbose()
+			} else {
+				setQuiet()
+			}
+			if len(args) == 0 {
+				if err := checkConfigFlags(cmd); err != nil {
+					prtty.Error.Fatal(err)
+				}
+				config, err := temple.ReadConfig(cmd.Flag("config").Value.String())
+				if err != nil {
+					prtty.Error.Fatal(err)
+				}
+				if cmd.Flag("strict").Value.String() == "true" {
+					for i := range config.Targets {
+						config.Targets[i].Strict = true
+					}
+				}
+				if err := config.Build(); err != nil {
+					prtty.Error.Fatal(err)
+				}
+				return
+			}
+			partials := cmd.Flag("partials").Value.String()
+			layouts := cmd.Flag("layouts").Value.String()
+			packageName := cmd.Flag("package").Value.String()
+			if err := temple.Build(args[0], args[1], partials, layouts, packageName, buildOptions(cmd)...); err != nil {
+				prtty.Error.Fatal(err)
+			}
+		},
+	}
+	addBuildFlags(cmdBuild)
+	cmdBuild.Flags().String("config", temple.DefaultConfigFile, "(optional) The config file which declares the targets to build when no arguments are given.")
+
+	// Define watch command
+	cmdWatch := &cobra.Command{
+		Use:   "watch <src> <dest>",
+		Short: "Build the templates and then build them again whenever a .tmpl file is added, changed, or removed.",
 		Long:  ``,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 2 {
-				prtty.Error.Fatal("temple build requires exactly 2 arguments: the src directory and the dest file.")
+				prtty.Error.Fatal("temple watch requires exactly 2 arguments: the src directory and the dest file.")
+			}
+			if verbose {
+				setVerbose()
+			} else {
+				setQuiet()
+			}
+			partials := cmd.Flag("partials").Value.String()
+			layouts := cmd.Flag("layouts").Value.String()
+			packageName := cmd.Flag("package").Value.String()
+			if err := temple.Watch(args[0], args[1], partials, layouts, packageName, buildOptions(cmd)...); err != nil {
+				prtty.Error.Fatal(err)
+			}
+		},
+	}
+	addBuildFlags(cmdWatch)
+
+	// Define check command
+	cmdCheck := &cobra.Command{
+		Use:   "check <src> [<dest>]",
+		Short: "Check the templates in the src directory for errors without writing any files, and optionally make sure the dest file is up to date.",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 && len(args) != 2 {
+				prtty.Error.Fatal("temple check requires 1 or 2 arguments: the src directory and optionally the dest file.")
 			}
 			if verbose {
 				setVerbose()
 			} else {
 				setQuiet()
 			}
+			dest := ""
+			if len(args) == 2 {
+				dest = args[1]
+			}
 			partials := cmd.Flag("partials").Value.String()
 			layouts := cmd.Flag("layouts").Value.String()
 			packageName := cmd.Flag("package").Value.String()
-			if err := temple.Build(args[0], args[1], partials, layouts, packageName); err != nil {
+			if err := temple.Check(args[0], dest, partials, layouts, packageName, buildOptions(cmd)...); err != nil {
 				prtty.Error.Fatal(err)
 			}
 		},
 	}
-	cmdBuild.Flags().String("partials", "", "(optional) The directory to look for partials. Partials are .tmpl files that are associated with layouts and all other templates.")
-	cmdBuild.Flags().String("layouts", "", "(optional) The directory to look for layouts. Layouts are .tmpl files which have access to partials and are associated with all other templates.")
-	cmdBuild.Flags().String("package", "", "(optional) The package name for the generated go file. If not provided, the default will be the directory where the go file is created.")
-	cmdBuild.Flags().BoolVarP(&verbose, "verbose", "v", false, "If set to true, temple will print out information while building.")
+	addBuildFlags(cmdCheck)
 
 	// Define version command
 	cmdVersion := &cobra.Command{
@@ -90,7 +243,7 @@ func main() {
 A command line tool for sharing go templates between a client and server.
 Visit https://github.com/albrow/temple for source code, example usage, documentation, and more.`,
 	}
-	rootCmd.AddCommand(cmdBuild, cmdVersion)
+	rootCmd.AddCommand(cmdBuild, cmdWatch, cmdCheck, cmdVersion)
 	if err := rootCmd.Execute(); err != nil {
 		prtty.Error.Fatal(err)
 	}
@@ -0,0 +1,52 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCheckConfigFlags(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args: []string{"--config=build/temple.json", "--strict", "-v"},
+		},
+		{
+			args:     []string{"--lazy", "--funcs=github.com/me/app/helpers.FuncMap", "--strict"},
+			expected: "temple: --funcs, --lazy cannot be used when building from a config file. Set the corresponding fields of the targets in the config file instead.",
+		},
+		{
+			// Flags which are set to their default values are still rejected.
+			args:     []string{"--mode=html"},
+			expected: "temple: --mode cannot be used when building from a config file. Set the corresponding fields of the targets in the config file instead.",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := &cobra.Command{}
+		addBuildFlags(cmd)
+		cmd.Flags().String("config", "", "")
+		if err := cmd.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		err := checkConfigFlags(cmd)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("Unexpected error in checkConfigFlags for %v: %s", tc.args, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Expected an error in checkConfigFlags for %v but got none", tc.args)
+		} else if err.Error() != tc.expected {
+			t.Errorf("Error from checkConfigFlags was not correct.\nExpected: %s\nBut got:  %s", tc.expected, err.Error())
+		}
+	}
+}
@@ -64,7 +64,7 @@ func (fi bindata_file_info) Sys() interface{} {
 	return nil
 }
 
-var _templates_generated_go_tmpl = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xb4\x53\x4d\x6f\xdb\x38\x10\x3d\x8b\xbf\x62\xe2\x93\x1c\x78\xa5\xdd\x05\xf6\x92\x45\x0e\x69\x1a\x04\x01\x12\x37\x40\xdc\x7b\x68\x6a\x2c\xb1\x95\x48\x81\x1c\xda\x31\x0c\xff\xf7\x0e\x25\xd9\xb1\xf3\xe1\x53\x7b\xb2\xc9\x79\x6f\xde\xe3\xcc\xd3\x66\x93\x9f\x8b\xe4\xda\xb6\x6b\xa7\xcb\x8a\xe0\xdf\xbf\xff\xf9\x0f\xae\x6a\x7c\x81\x2f\xce\xae\x0c\x66\x22\xb9\xaa\x6b\xe8\x8a\x1e\x1c\x7a\x74\x4b\x2c\x32\xf8\xee\x11\xec\x02\xa8\xd2\x1e\xbc\x0d\x4e\x21\x28\x5b\x20\x68\x2f\x92\xd2\x2e\xd1\x19\x2c\x60\xbe\x66\x00\xc2\xc3\xdd\x0c\x6a\xad\xd0\x78\x9c\xc0\xaa\xd2\xaa\x02\x25\x0d\xcc\x11\x16\x36\x98\x42\x24\xda\x74\xb8\xfb\xbb\xeb\x9b\xe9\xd3\x0d\x2c\x74\xcd\xba\x22\x99\x7e\x9b\xdd\x5c\xf4\x12\xf1\x8a\x7b\x03\x36\x73\x2c\x0a\x6e\xbd\xd4\x12\x4a\xfb\xd7\x5c\x9b\x42\x92\x84\xb4\x22\x6a\xfd\x45\x9e\x97\x9a\xaa\x30\xcf\x94\x6d\xf2\x1f\x84\x18\x56\x68\xf2\x57\xdc\x58\x24\x77\x0b\x58\xdb\x00\xaa\x92\xa6\xe4\x96\x34\x89\x3e\x7c\x70\x08\x64\xc1\x05\xc3\x5d\xa1\x44\x83\x4e\x12\x42\x96\x67\x59\xb6\xe7\x18\x64\x61\x46\x69\xe3\x49\xf2\x50\xa2\xe7\x03\x0f\xf8\x82\x2a\x90\x9c\xd7\xfc\xca\x7d\x23\x82\xd3\x8e\xc4\x79\xbe\xdd\x8a\x56\xaa\x9f\x92\xed\x6c\x36\x90\x3d\xf6\xff\xa7\xb2\x41\xe0\x92\xc8\x73\x98\xc5\x11\xec\x30\x95\xf4\x6c\x19\x0d\xc8\x40\xb6\x91\xa4\x15\x7b\x59\xef\x3d\x17\xb0\x62\x41\x20\x6c\xda\x38\x45\x66\x7f\xb5\x60\x2c\x01\x16\x9a\xa0\x91\x26\x44\xf8\x99\x10\xba\x69\xad\x23\x48\x45\x32\x3a\xb0\xc8\xce\xaa\xd0\xf0\x1b\xf2\xbe\xc3\xf0\x33\x12\x63\x21\x96\xd2\x45\xf8\x2d\xd2\x2c\x5e\xc6\x01\x2d\x82\x51\xa9\x89\x56\x3d\x39\x6d\xca\x31\xa4\xe7\x83\xf4\x0e\x33\x01\x74\xce\xba\x71\x47\x7c\x94\x8e\xb4\xac\x4f\xf1\x06\xc8\x11\xed\x5e\xf2\xfc\xe9\x14\xab\x47\xbc\x92\x1e\x82\xa7\xd3\x46\xdf\xfa\xdc\x73\x3e\xf7\xf8\xc6\xe2\x9e\xf1\xa9\xbd\x63\x77\x71\x86\x11\xc3\x01\xd2\x94\x8e\x61\x23\x92\x38\x52\xb6\xdc\xdb\xe6\x2f\x07\x2e\x2e\x77\xab\x9b\xe2\xea\xd6\xd9\xd0\xa6\xfc\x1a\xce\x85\xeb\xf2\xba\x93\xf6\x31\x1a\x89\x5e\x74\xe4\x4b\x28\xb3\xab\xa2\x18\x4a\xe9\x28\xa6\x68\x88\xcf\x68\x02\xcf\xf1\xf8\xe4\x14\x9f\x9e\xc7\xff\x77\x84\xb3\x4b\x30\xba\x8e\xfa\x49\x2b\x8d\x56\x29\x5f\xb2\xca\xb6\x13\x42\x53\x74\xb9\x3b\x10\xed\xed\x7f\xa4\xd9\x57\xfe\x80\xe4\x6e\x2b\x1f\x89\xee\x6a\xbf\x49\xf6\x28\xd0\x51\xe2\xe0\x7c\x14\xda\xa1\xb6\xdf\xfe\xeb\xe6\x87\xca\xb0\xe6\x77\xe1\x8b\xe5\x37\x77\xef\xd2\x76\x80\xf9\x24\x5e\x07\x88\x41\x68\xfb\x2b\x00\x00\xff\xff\x1e\x4e\x7e\x4b\xbe\x05\x00\x00")
+var _templates_generated_go_tmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x57\x4d\x73\xdb\x36\x13\x3e\x13\xbf\x62\xa3\x93\x98\x51\xc8\xf7\xed\x4c\x2f\xe9\xf8\xe0\x26\x4e\xc6\x33\x8e\xeb\x69\xdc\xc9\x19\x22\x96\x24\x5a\x0a\x60\x81\xa5\x65\x95\xc3\xff\xde\x01\x40\x88\x94\x14\xd9\x1e\x37\x27\xd3\xd8\xaf\x67\x17\xcf\xee\x42\x7d\x9f\xbf\x65\xc9\x07\xdd\xee\x8c\xac\x6a\x82\x9f\xfe\xf7\xff\x9f\xe1\xb2\xc1\x47\xf8\xd5\xe8\xad\xc2\x8c\x25\x97\x4d\x03\x5e\x68\xc1\xa0\x45\xf3\x80\x22\x83\x3f\x2c\x82\x2e\x81\x6a\x69\xc1\xea\xce\x14\x08\x85\x16\x08\xd2\xb2\xa4\xd2\x0f\x68\x14\x0a\x58\xef\x80\x6a\x84\x2f\xd7\xf7\xd0\xc8\x02\x95\xc5\x15\x6c\x6b\x59\xd4\x50\x70\x05\x6b\x84\x52\x77\x4a\xb0\x44\x2a\xaf\x77\x73\xfd\xe1\xea\xf6\xeb\x15\x94\xb2\xc1\x8c\xb1\xe4\xf6\xb7\xfb\xab\xf7\x21\x84\x3b\x02\x69\x01\x37\x6b\x14\x02\x05\x3c\x48\x0e\x95\x7e\xb7\x96\x4a\x70\xe2\xb0\xac\x89\x5a\xfb\x3e\xcf\x2b\x49\x75\xb7\xce\x0a\xbd\xc9\xff\x24\xc4\x6e\x8b\x2a\x9f\xf4\x52\x96\x5c\x97\xb0\xd3\x1d\x14\x35\x57\x15\x82\xa4\x95\xc3\x61\x3b\x83\x40\x1a\x4c\xa7\xa0\xd2\x50\xa1\x42\xc3\x09\x21\xcb\xb3\x2c\xdb\xdb\x28\x44\xe1\xb4\xa4\xb2\xc4\x9b\xc6\x63\x9e\x61\xc0\x47\x2c\x3a\xe2\xeb\x06\x57\x93\x23\x82\xa7\x11\xb1\xb7\xf9\x30\xb0\x96\x17\x7f\xf1\x0a\xa1\xef\x21\xbb\x0b\xdf\xb7\x7c\x83\x30\x0c\x8c\xe5\x39\xdc\xbb\x12\x44\x9d\x9a\x5b\x58\x23\x2a\xe0\x1d\xe9\x0d\x27\x59\xf0\xa6\xd9\xed\x31\x0b\xd8\x4a\xaa\x81\x70\xd3\xba\x2a\xe6\x39\x7c\xd4\xa0\x34\x01\x0a\x49\xb0\xe1\xaa\x73\xea\x6f\x18\x93\x9b\x56\x1b\x82\x25\x4b\x16\x52\x2f\x58\xd2\xf7\x20\x4b\xc8\xee\x0c\x16\x7a\xd3\xba\x7a\x0f\xc3\x82\xf0\x91\x72\xef\x8b\x13\xe6\x2d\x37\x16\x17\x7d\x0f\xa8\x84\xc7\x96\x2c\x66\xd9\x55\xfa\x5d\xdd\x6d\xd6\x0d\x06\x83\xf8\x67\x72\xfd\xa9\x53\x85\x85\x61\xe8\xfb\xf1\x3b\xbb\x6c\x24\x77\x27\xb0\x98\xce\xae\x3d\xae\x3b\x4e\xb5\x03\x30\x05\x73\x5e\x8c\xbf\xb4\xec\x23\x27\x1e\xd4\x6c\x94\x1c\xb9\x8a\xe6\x4e\xf6\x2e\x7a\x48\xc7\x62\x22\x28\xbe\x41\xeb\xf8\x1b\xaf\x31\xa6\x68\x57\xd0\x72\x43\x92\x37\x76\x05\x5c\x09\x68\xf8\x4e\x77\x64\xb3\x03\x33\xe7\x26\xaa\xcd\xb5\x40\xaa\xa2\xe9\x04\x3a\x97\xd2\x40\x6b\xb0\x94\x8f\x19\x2b\xb4\xb2\xbe\xd0\x0e\xcb\x98\xc2\x7d\x0c\xb8\x4f\xc0\x5d\xf8\x07\xaf\x39\x0c\x70\xe1\xa8\xf0\x77\xa7\x09\xfd\x8d\x94\xf2\x11\x45\x64\xc4\x3c\xa5\xb9\xcb\xbb\x88\xe8\x87\x79\xbc\x19\xd3\xfa\x4f\x0e\x53\xc6\x1e\xb8\x71\xe9\x7f\x46\x8a\x69\x43\xd9\xa9\x62\xe9\xea\x09\x96\x8c\x54\x55\x0a\xcb\xb7\x23\x67\xa3\xce\x0a\xd0\x18\x6d\x52\x6f\x38\x26\xf7\x94\xdd\xa8\x72\x60\x16\x32\x78\xca\x2a\x68\x4c\x46\x5f\x3a\x4b\x4f\x03\x3d\xc6\xb9\xb7\x39\x8f\xf1\x08\xe2\xde\xe2\x2c\xbc\x43\x74\x2c\xc9\x73\xb8\xf2\xe3\x05\xbf\x49\xaa\x47\x33\x83\x4a\xa0\xb1\x07\x04\x06\x29\x50\x91\x2c\x65\x18\xbf\xde\xa9\x54\x56\x06\x52\x7a\x47\x81\xac\x47\x8a\xe1\x30\x63\xc9\x69\x18\x8f\x6e\x6b\x40\xea\xec\x9b\x91\x84\x66\xe5\xdd\xae\xa2\xa3\x80\x79\x05\x7e\x08\x4a\x45\x68\x4a\x5e\x60\x3f\xa4\xa1\xa6\xfb\xf6\xbf\xe1\xff\xec\x3c\x39\xf2\x1c\x6e\x34\x17\xe0\xc7\x89\x7d\x71\x0f\xc6\xdd\xa1\xa9\x46\xb3\x95\x36\x64\xc3\x0d\x06\x47\xc2\x3b\x29\xa5\xb1\x04\x24\x37\x3e\xdf\x9d\x17\x2b\x44\x81\x22\x83\x6b\x57\x32\xea\x8c\xb2\x33\xd5\x11\x63\x9e\x03\xd5\x9c\x40\x17\x45\x67\x6c\xc6\x12\x8f\xd0\xa7\x3e\xcf\x63\xe2\x74\xdf\x83\xc0\x52\x2a\x84\x05\x17\x62\xbc\xd8\x45\x98\x6e\x2e\xdb\x7b\x83\xbe\xb9\xab\xec\x72\x2f\xf6\x67\xcb\xa9\x6d\xc6\x76\x59\xf9\xb9\x1f\x0d\x52\x17\xa6\xb1\x78\x64\x7b\xc6\x6c\x3c\xfa\x6a\x8a\x68\xea\x11\x4e\x50\x0f\x71\x86\x2b\x3d\x07\x33\x48\x5f\x85\x32\x98\xfe\x18\x90\xb1\xb1\xce\xc1\x8c\xf2\x57\x01\x8d\xc6\xaf\x85\xca\x1c\x27\x40\x2a\x49\xcb\x14\xfa\x30\xe9\x64\xe9\x37\xec\xc4\x70\x37\xf0\xd0\x98\x89\x38\xd3\x78\xad\xe0\xfd\x45\xdc\xce\xb7\xb8\x8d\xf9\xe1\x23\x7d\xd1\xc2\xc1\x70\x9f\xfb\x70\x9f\x8d\xee\xda\x65\x7a\xb2\x42\x59\x52\x6a\x33\xb6\x61\xe9\x3c\x86\x89\x7d\xba\x59\xb3\xe9\x68\x4c\xd3\x81\x4e\x7c\x29\x3e\xc5\xb1\xb3\x82\x32\x65\xc9\x30\x67\xf8\x49\xd3\x36\x47\xc0\x9d\x20\xa0\xab\xd2\xf9\x6e\x3e\xd8\x42\xcd\x01\x83\xe7\xdd\xe4\x51\x84\x6e\x74\xa8\xf7\xe3\xeb\xa0\x99\x32\xef\x64\x48\xe7\xc0\xe6\xb1\xe6\xfb\xa9\x99\xd3\xf0\xa5\x91\x62\x3b\x3c\x17\xe8\x70\x5b\x37\xcf\x12\xe9\xa5\xf1\x27\xa6\x7f\x0f\xc1\xc1\xc2\xbc\x80\xa6\xca\x66\x07\x07\x5b\x31\x0a\xf7\xfb\x65\xda\x2d\x51\x14\x37\xc9\xf1\x7e\xf3\xf2\xa3\xc3\x93\x8d\x36\x57\x3a\xb3\xc3\xe6\x2a\x31\xd6\xe9\x2e\xf1\x5a\x27\xc7\xe3\xb4\xf5\x42\xf7\xc5\x92\xa9\x69\xcf\x72\x4b\x96\xbe\xc5\x2e\x9e\xa6\xcf\x2f\x5e\xe9\xcd\x05\x28\xd9\xf8\x9b\x68\xb9\x92\xc5\x12\x8d\x39\x66\xfc\x39\x62\x9d\x8d\x33\x27\xcf\xeb\xc2\x1c\xd2\xea\x6c\xa0\x43\x96\xbc\x3c\xd4\x11\x7f\x9e\xa2\xcf\x79\xf6\x3c\x43\x9e\x97\x70\xe7\x79\xea\xbc\x88\x39\xdf\x25\xce\x94\xed\xc0\xce\x14\x36\xcf\xfd\x5c\xfc\xdd\xbf\x96\xdc\xcc\x83\x61\xf8\xfe\xdb\xc9\xcd\x42\x01\x27\xfd\x1c\x7e\x51\xf9\x5f\x6b\xee\xc1\xa1\x04\x6c\x8d\x24\x0c\xc6\xba\xa3\xb6\x23\x20\x0d\x5b\x93\x85\xe5\x70\x12\xed\xe8\xf5\xe4\x3c\x79\x25\xf7\x3b\xe6\x7e\xd7\xba\x18\xb3\x61\x41\x9b\x36\x3c\x62\xdd\xc0\x9d\x55\x76\x79\xfc\x04\x4f\xf7\x9c\x99\xb1\x61\x9c\x34\x68\x8c\x67\xc3\xf8\xaf\xf3\x19\xcb\xb7\xdc\x8e\x18\xd2\x50\xb3\x50\xbf\x7f\x07\x00\xdd\x08\x0f\x8b\x09\x10\x00\x00")
 
 func templates_generated_go_tmpl_bytes() ([]byte, error) {
 	return bindata_read(
@@ -79,7 +79,7 @@ func templates_generated_go_tmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindata_file_info{name: "templates/generated.go.tmpl", size: 1470, mode: os.FileMode(420), modTime: time.Unix(1432862983, 0)}
+	info := bindata_file_info{name: "templates/generated.go.tmpl", size: 4105, mode: os.FileMode(420), modTime: time.Unix(1792267785, 0)}
 	a := &asset{bytes: bytes, info:  info}
 	return a, nil
 }
@@ -8,90 +8,496 @@ package temple
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"github.com/albrow/prtty"
 	"github.com/go-humble/temple/temple/assets"
 	"go/format"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
+// A BuildOption changes the way that Build compiles templates and
+// generates code.
+type BuildOption func(*buildOptions)
+
+// buildOptions holds the settings which can be changed by passing a
+// BuildOption to Build.
+type buildOptions struct {
+	funcs      *funcsVar
+	precompile bool
+	lazy       bool
+	mode       Mode
+	dryRun     bool
+	strict     bool
+	files      *fileMatcher
+	log        *buildLog
+	err        error
+}
+
+// buildLog holds the loggers which Build prints messages with.
+type buildLog struct {
+	normal  *prtty.Logger
+	info    *prtty.Logger
+	warn    *prtty.Logger
+	success *prtty.Logger
+}
+
+// newBuildLog returns a buildLog which prints every message to w.
+func newBuildLog(w io.Writer) *buildLog {
+	return &buildLog{
+		normal:  &prtty.Logger{Output: w},
+		info:    &prtty.Logger{Output: w},
+		warn:    &prtty.Logger{Output: w},
+		success: &prtty.Logger{Output: w},
+	}
+}
+
+// WithFuncs causes Build to install a map of template functions before
+// any templates, partials, or layouts are added. funcs identifies an exported
+// variable in an importable package and must have the form
+// "import/path.VarName", e.g. "github.com/me/app/helpers.FuncMap". The
+// variable must be declared with a map literal with string literal keys
+// (typically a template.FuncMap) so that the function names can be found
+// when checking the templates for compilation errors. The generated code
+// imports the package and calls AddFunc for each function in the map.
+func WithFuncs(funcs string) BuildOption {
+	return func(opts *buildOptions) {
+		opts.funcs, opts.err = parseFuncsVar(funcs)
+	}
+}
+
+// WithPrecompile causes Build to generate code which holds the parse
+// tree for each template, partial, and layout instead of its source.
+// The generated code adds them to the group with AddTemplateTrees,
+// AddPartialTrees, and AddLayoutTrees, so no templates need to be
+// parsed when the generated package is initialized. Since the source
+// is not included, errors that occur while executing a precompiled
+// template will not include line numbers.
+func WithPrecompile() BuildOption {
+	return func(opts *buildOptions) {
+		opts.precompile = true
+	}
+}
+
+// WithLazy causes Build to generate code which parses each template the
+// first time it is requested instead of parsing all of them when the
+// generated package is initialized. All the partials and layouts are
+// parsed together the first time anything is requested. The generated
+// code also includes a Load function which parses everything at once and
+// returns the first error, so callers can opt into validating all the
+// templates without risking a panic when the package is imported.
+func WithLazy() BuildOption {
+	return func(opts *buildOptions) {
+		opts.lazy = true
+	}
+}
+
+// WithMode causes Build to compile templates in the given mode, which
+// must be either "html" or "text". The generated code creates its group
+// with NewTextGroup instead of NewGroup in text mode, so the templates are
+// executed with text/template and nothing is escaped. The default mode is
+// "html".
+func WithMode(mode string) BuildOption {
+	return func(opts *buildOptions) {
+		opts.mode, opts.err = ParseMode(mode)
+	}
+}
+
+// WithExtensions causes Build to collect files with the given extensions,
+// e.g. ".tmpl", ".html", and ".gohtml", instead of DefaultExtensions. The
+// name of each template, partial, and layout is based on its filename
+// without the extension. If a filename ends with more than one of the
+// extensions, the first one that matches is used.
+func WithExtensions(extensions ...string) BuildOption {
+	return func(opts *buildOptions) {
+		opts.files = newFileMatcher(extensions, opts.files.ignore)
+	}
+}
+
+// WithIgnore causes Build to skip any files and directories in the
+// templates, partials, and layouts directories which match one of the
+// given patterns, in addition to any patterns in the IgnoreFile in each of
+// those directories. Patterns are matched with path.Match against the path
+// relative to the directory, using forward slashes. A pattern without a
+// slash, such as "*.swp" or "node_modules", matches files or directories
+// with that name anywhere, and a pattern which ends with a slash only
+// matches directories.
+func WithIgnore(patterns ...string) BuildOption {
+	return func(opts *buildOptions) {
+		for _, pattern := range patterns {
+			if opts.err = checkIgnorePattern(pattern); opts.err != nil {
+				return
+			}
+		}
+		opts.files = newFileMatcher(opts.files.extensions, append(opts.files.ignore, patterns...))
+	}
+}
+
+// WithDryRun causes Build to check the templates and generate code
+// without writing it to the dest file. Use BuildWithConfig to get the
+// generated code.
+func WithDryRun() BuildOption {
+	return func(opts *buildOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithStrict causes Build and Check to return an error if there are any
+// warnings, such as partials or layouts which are not used by any template.
+// Without it, warnings are printed but do not cause the build to fail.
+func WithStrict() BuildOption {
+	return func(opts *buildOptions) {
+		opts.strict = true
+	}
+}
+
+// WithLog causes Build to print messages to w instead of using the
+// loggers from the prtty package.
+func WithLog(w io.Writer) BuildOption {
+	return func(opts *buildOptions) {
+		opts.log = newBuildLog(w)
+	}
+}
+
 // Build is the function called when you run the build sub-command
 // in the command line tool. It compiles all the templates in the
 // src directory and generates go code in the dest file. If partials
 // and/or layouts are provided, it will add them to the generated file
 // with calls to AddPartial and AddLayout. If packageName is an empty
 // string, the package name will be the directory of the dest file.
-func Build(src, dest, partials, layouts, packageName string) error {
-	prtty.Info.Println("--> building...")
-	prtty.Default.Printf("    src: %s", src)
-	prtty.Default.Printf("    dest: %s", dest)
+// Any options will be applied in order.
+func Build(src, dest, partials, layouts, packageName string, options ...BuildOption) error {
+	opts, err := newBuildOptions(options)
+	if err != nil {
+		return err
+	}
+	dirs := sourceDirGroup{
+		templates: src,
+		partials:  partials,
+		layouts:   layouts,
+	}
+	_, err = runBuild(dirs, dest, packageName, opts)
+	return err
+}
+
+// BuildConfig holds the arguments and options for BuildWithConfig. Src,
+// Dest, Partials, Layouts, and Package correspond to the arguments of
+// Build. Funcs, Mode, Extensions, and Ignore are passed to WithFuncs,
+// WithMode, WithExtensions, and WithIgnore if they are not empty, Log is
+// passed to WithLog if it is not nil, and the rest of the fields correspond
+// to the other BuildOptions.
+type BuildConfig struct {
+	Src        string
+	Dest       string
+	Partials   string
+	Layouts    string
+	Package    string
+	Funcs      string
+	Precompile bool
+	Lazy       bool
+	Mode       string
+	Extensions []string
+	Ignore     []string
+	DryRun     bool
+	Strict     bool
+	Log        io.Writer
+}
+
+// BuildResult holds the results of BuildWithConfig. Files holds the
+// filename of every template, partial, and layout that was collected,
+// Code holds the generated go code, and Warnings holds any problems with
+// the templates which did not cause the build to fail, such as partials
+// which are not used by any template.
+type BuildResult struct {
+	Files    []string
+	Code     []byte
+	Warnings []string
+}
+
+// BuildWithConfig works like Build, except that it takes all of its
+// arguments and options from cfg and returns the results of the build.
+// Unlike Build, it can be used without writing anything to the dest file
+// (by setting DryRun) or printing anything with the loggers from the prtty
+// package (by setting Log), which makes it useful for other tools which use
+// temple as a library.
+func BuildWithConfig(cfg BuildConfig) (*BuildResult, error) {
+	opts, err := newBuildOptions(cfg.options())
+	if err != nil {
+		return nil, err
+	}
+	dirs := sourceDirGroup{
+		templates: cfg.Src,
+		partials:  cfg.Partials,
+		layouts:   cfg.Layouts,
+	}
+	return runBuild(dirs, cfg.Dest, cfg.Package, opts)
+}
+
+// options returns the BuildOptions which correspond to the fields of cfg.
+func (cfg BuildConfig) options() []BuildOption {
+	options := []BuildOption{}
+	if cfg.Funcs != "" {
+		options = append(options, WithFuncs(cfg.Funcs))
+	}
+	if cfg.Precompile {
+		options = append(options, WithPrecompile())
+	}
+	if cfg.Lazy {
+		options = append(options, WithLazy())
+	}
+	if cfg.Mode != "" {
+		options = append(options, WithMode(cfg.Mode))
+	}
+	if len(cfg.Extensions) > 0 {
+		options = append(options, WithExtensions(cfg.Extensions...))
+	}
+	if len(cfg.Ignore) > 0 {
+		options = append(options, WithIgnore(cfg.Ignore...))
+	}
+	if cfg.DryRun {
+		options = append(options, WithDryRun())
+	}
+	if cfg.Strict {
+		options = append(options, WithStrict())
+	}
+	if cfg.Log != nil {
+		options = append(options, WithLog(cfg.Log))
+	}
+	return options
+}
+
+// runBuild does the work for Build and BuildWithConfig. It checks that the
+// templates, partials, and layouts in dirs compile and then generates go
+// code for them, which is written to dest unless opts has dryRun set.
+func runBuild(dirs sourceDirGroup, dest, packageName string, opts *buildOptions) (*BuildResult, error) {
+	opts.log.info.Println("--> building...")
+	opts.print(dirs.templates, dest, dirs.partials, dirs.layouts, packageName)
+	warnings, err := checkCompileTemplates(dirs, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newTemplateData(dirs, dest, packageName, opts)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := data.execute()
+	if err != nil {
+		return nil, err
+	}
+	if !opts.dryRun {
+		if err := ioutil.WriteFile(dest, generated, os.ModePerm); err != nil {
+			return nil, err
+		}
+		opts.log.success.Printf("    created %s", dest)
+	}
+	opts.log.info.Println("--> done!")
+	return &BuildResult{
+		Files:    data.filenames(),
+		Code:     generated,
+		Warnings: warnings,
+	}, nil
+}
+
+// newBuildOptions applies options in order and returns the result.
+func newBuildOptions(options []BuildOption) (*buildOptions, error) {
+	opts := &buildOptions{
+		files: newFileMatcher(nil, nil),
+		log: &buildLog{
+			normal:  prtty.Default,
+			info:    prtty.Info,
+			warn:    prtty.Warn,
+			success: prtty.Success,
+		},
+	}
+	for _, option := range options {
+		option(opts)
+		if opts.err != nil {
+			return nil, opts.err
+		}
+	}
+	return opts, nil
+}
+
+// print prints the arguments and options for Build or Check.
+func (opts *buildOptions) print(src, dest, partials, layouts, packageName string) {
+	opts.log.normal.Printf("    src: %s", src)
+	if dest != "" {
+		opts.log.normal.Printf("    dest: %s", dest)
+	}
 	if partials != "" {
-		prtty.Default.Printf("    partials: %s", partials)
+		opts.log.normal.Printf("    partials: %s", partials)
 	}
 	if layouts != "" {
-		prtty.Default.Printf("    layouts: %s", layouts)
+		opts.log.normal.Printf("    layouts: %s", layouts)
 	}
 	if packageName != "" {
-		prtty.Default.Printf("    package: %s", packageName)
+		opts.log.normal.Printf("    package: %s", packageName)
 	}
-	dirs := sourceDirGroup{
-		templates: src,
-		partials:  partials,
-		layouts:   layouts,
+	if opts.funcs != nil {
+		opts.log.normal.Printf("    funcs: %s", opts.funcs)
 	}
-	if err := checkCompileTemplates(dirs); err != nil {
-		return err
+	if opts.precompile {
+		opts.log.normal.Printf("    precompile: true")
 	}
-	if err := generateFile(dirs, dest, packageName); err != nil {
-		return err
+	if opts.lazy {
+		opts.log.normal.Printf("    lazy: true")
+	}
+	if opts.mode != HTMLMode {
+		opts.log.normal.Printf("    mode: %s", opts.mode)
+	}
+	if !reflect.DeepEqual(opts.files.extensions, DefaultExtensions) {
+		opts.log.normal.Printf("    extensions: %s", strings.Join(opts.files.extensions, ", "))
+	}
+	if len(opts.files.ignore) > 0 {
+		opts.log.normal.Printf("    ignore: %s", strings.Join(opts.files.ignore, ", "))
+	}
+	if opts.dryRun {
+		opts.log.normal.Printf("    dry run: true")
+	}
+	if opts.strict {
+		opts.log.normal.Printf("    strict: true")
 	}
-	prtty.Info.Println("--> done!")
-	return nil
 }
 
 // checkCompileTemplates compiles the templates, partials, and layouts
 // in dirs with the correct associations to make sure that the templates
 // compile. If they don't, we can catch errors early and return them when
-// the command line tool is invoked, instead of at runtime.
-func checkCompileTemplates(dirs sourceDirGroup) error {
-	prtty.Info.Println("--> checking for compilation errors...")
+// the command line tool is invoked, instead of at runtime. If opts includes
+// a funcs variable, a stub is added to the group for each function it
+// declares. Every template called with the `template` action must exist,
+// and any templates which declare the type of their data are also checked
+// against that type. Any partials and layouts which are not used by any
+// template are returned as warnings, or as an error if opts has strict set.
+func checkCompileTemplates(dirs sourceDirGroup, opts *buildOptions) (warnings []string, err error) {
+	opts.log.info.Println("--> checking for compilation errors...")
 	if dirs.templates == "" {
-		return errors.New("temple: templates dir cannot be an empty string.")
+		return nil, errors.New("temple: templates dir cannot be an empty string.")
 	}
 	g := NewGroup()
+	if opts.mode == TextMode {
+		g = NewTextGroup()
+	}
+	funcs, err := opts.stubFuncs()
+	if err != nil {
+		return nil, err
+	}
+	for name, f := range funcs {
+		g.AddFunc(name, f)
+	}
 	if dirs.partials != "" {
-		prtty.Default.Println("    checking partials...")
-		if err := g.AddPartialFiles(dirs.partials); err != nil {
-			return err
+		opts.log.normal.Println("    checking partials...")
+		if err := addCheckFiles(dirs.partials, opts.files, funcs, g.AddPartialTrees); err != nil {
+			return nil, err
 		}
 	}
 	if dirs.layouts != "" {
-		prtty.Default.Println("    checking layouts...")
-		if err := g.AddLayoutFiles(dirs.layouts); err != nil {
+		opts.log.normal.Println("    checking layouts...")
+		if err := addCheckFiles(dirs.layouts, opts.files, funcs, g.AddLayoutTrees); err != nil {
+			return nil, err
+		}
+	}
+	opts.log.normal.Println("    checking templates...")
+	if err := addCheckFiles(dirs.templates, opts.files, funcs, g.AddTemplateTrees); err != nil {
+		return nil, err
+	}
+	if err := g.Compile(); err != nil {
+		return nil, err
+	}
+	opts.log.normal.Println("    checking template references...")
+	unused, err := checkTemplateRefs(g)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range unused {
+		opts.log.warn.Printf("    %s", warning)
+		warnings = append(warnings, warning)
+	}
+	opts.log.normal.Println("    checking data types...")
+	if err := checkTemplateData(g, newTypeLoader()); err != nil {
+		return nil, err
+	}
+	if opts.strict && len(warnings) > 0 {
+		return nil, fmt.Errorf("temple: warnings are not allowed in strict mode:\n%s", strings.Join(warnings, "\n"))
+	}
+	return warnings, nil
+}
+
+// addCheckFiles parses each of the files in dir which are collected by files
+// and adds the trees to a group with add, which should be one of
+// AddTemplateTrees, AddPartialTrees, or AddLayoutTrees. The ParseName of each
+// tree is set to the filename, so that errors found by checkTemplateData
+// include it. funcs only needs to hold the names of any functions the files
+// call.
+func addCheckFiles(dir string, files *fileMatcher, funcs template.FuncMap, add func(name string, trees map[string]*parse.Tree) error) error {
+	return files.collect(dir, func(name, filename string) error {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		trees, err := parseSource(name, string(src), funcs)
+		if err != nil {
 			return err
 		}
+		for _, tree := range trees {
+			tree.ParseName = filename
+		}
+		return add(name, trees)
+	})
+}
+
+// stubFuncs returns a FuncMap with a stub for each function declared
+// by the funcs variable in opts, or an empty FuncMap if there is none.
+func (opts *buildOptions) stubFuncs() (template.FuncMap, error) {
+	funcs := template.FuncMap{}
+	if opts.funcs == nil {
+		return funcs, nil
 	}
-	prtty.Default.Println("    checking templates...")
-	if err := g.AddTemplateFiles(dirs.templates); err != nil {
-		return err
+	names, err := opts.funcs.funcNames()
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	for _, name := range names {
+		funcs[name] = stubFunc
+	}
+	return funcs, nil
 }
 
 // templateData is passed in to the template for the generated code.
 type templateData struct {
 	PackageName string
+	Funcs       *funcsVar
+	Precompile  bool
+	Lazy        bool
+	TextMode    bool
+	DataImports []dataImport
 	Templates   []sourceFile
 	Partials    []sourceFile
 	Layouts     []sourceFile
 }
 
 // sourceFile represents the source file for a template, partial, or layout.
+// Trees holds go code for the parse trees of the source, and is only set when
+// precompiling. NameConst holds the name of the generated constant for the
+// name, and PrefixedName holds its value. RenderFunc and DataType hold the
+// name of the generated render function and the type of its data argument,
+// and are only set for regular templates. Filename is the file that the
+// source was read from.
 type sourceFile struct {
-	Name string
-	Src  string
+	Name         string
+	Filename     string
+	Src          string
+	Trees        string
+	NameConst    string
+	PrefixedName string
+	RenderFunc   string
+	DataType     string
 }
 
 // sourceDirGroup represents a group of source directories, consisting of a
@@ -107,25 +513,25 @@ type sourceDirGroup struct {
 // collectAllSourceFiles walks recursively through the directories in dirs
 // and collects all template, partial, and layout source files, adding them
 // to data.
-func (data *templateData) collectAllSourceFiles(dirs sourceDirGroup) error {
+func (data *templateData) collectAllSourceFiles(dirs sourceDirGroup, opts *buildOptions) error {
 	if dirs.partials != "" {
-		prtty.Info.Println("--> collecting partials...")
-		partials, err := collectSourceFiles(dirs.partials)
+		opts.log.info.Println("--> collecting partials...")
+		partials, err := collectSourceFiles(dirs.partials, opts)
 		if err != nil {
 			return err
 		}
 		data.Partials = partials
 	}
 	if dirs.layouts != "" {
-		prtty.Info.Println("--> collecting layouts...")
-		layouts, err := collectSourceFiles(dirs.layouts)
+		opts.log.info.Println("--> collecting layouts...")
+		layouts, err := collectSourceFiles(dirs.layouts, opts)
 		if err != nil {
 			return err
 		}
 		data.Layouts = layouts
 	}
-	prtty.Info.Println("--> collecting templates...")
-	templates, err := collectSourceFiles(dirs.templates)
+	opts.log.info.Println("--> collecting templates...")
+	templates, err := collectSourceFiles(dirs.templates, opts)
 	if err != nil {
 		return err
 	}
@@ -133,64 +539,116 @@ func (data *templateData) collectAllSourceFiles(dirs sourceDirGroup) error {
 	return nil
 }
 
-// generateFile generates go code containing the contents of all the
-// files in the sourceDirGroup and writes the code to the dest file. It
-// uses the given packageName if it is non-empty, and otherwise falls back
-// to the directory that dest is in. If a file already exists at dest, it
-// will be overwritten.
-func generateFile(dirs sourceDirGroup, dest, packageName string) error {
-	prtty.Info.Println("--> generating go code...")
+// newTemplateData collects the source files for all the templates,
+// partials, and layouts in dirs and returns the templateData for the code
+// that is generated for them. It uses the given packageName if it is
+// non-empty, and otherwise falls back to the directory that dest is in. If
+// opts includes a funcs variable, the generated code will install the
+// functions it holds before adding any templates. If opts has precompile
+// set, the generated code will hold parse trees instead of source, and if
+// opts has lazy set, the generated code will parse templates when they are
+// first needed. If opts has the text mode set, the generated code will use a
+// group created with NewTextGroup. A constant is generated for the name of
+// each template, partial, and layout, and a typed render function for each
+// regular template.
+func newTemplateData(dirs sourceDirGroup, dest, packageName string, opts *buildOptions) (*templateData, error) {
+	opts.log.info.Println("--> generating go code...")
 	if packageName == "" {
 		packageName = filepath.Base(filepath.Dir(dest))
 	}
 	data := &templateData{
 		PackageName: packageName,
+		Funcs:       opts.funcs,
+		Precompile:  opts.precompile,
+		Lazy:        opts.lazy,
+		TextMode:    opts.mode == TextMode,
 	}
-	if err := data.collectAllSourceFiles(dirs); err != nil {
-		return err
+	if err := data.collectAllSourceFiles(dirs, opts); err != nil {
+		return nil, err
+	}
+	data.addNameConsts()
+	funcs, err := opts.stubFuncs()
+	if err != nil {
+		return nil, err
+	}
+	if err := data.addRenderFuncs(funcs); err != nil {
+		return nil, err
 	}
-	if err := data.writeToFile(dest); err != nil {
+	if opts.precompile {
+		if err := data.precompile(opts); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// filenames returns the filename of each of the collected source files.
+func (data *templateData) filenames() []string {
+	filenames := []string{}
+	for _, files := range [][]sourceFile{data.Partials, data.Layouts, data.Templates} {
+		for _, file := range files {
+			filenames = append(filenames, file.Filename)
+		}
+	}
+	return filenames
+}
+
+// precompile sets Trees for each of the collected source files.
+func (data *templateData) precompile(opts *buildOptions) error {
+	opts.log.info.Println("--> precompiling...")
+	funcs, err := opts.stubFuncs()
+	if err != nil {
 		return err
 	}
+	for _, files := range [][]sourceFile{data.Partials, data.Layouts, data.Templates} {
+		for i, file := range files {
+			trees, err := parseTreesLiteral(file.Name, file.Src, funcs)
+			if err != nil {
+				return err
+			}
+			files[i].Trees = trees
+		}
+	}
 	return nil
 }
 
 //go:generate go-bindata --pkg=assets -o=assets/bindata.go templates/...
 
-// writeToFile writes the given templateData to the file located
-// at dest. It uses the template located at templates/generated.go.tmpl.
-// If there is already a file located at dest, it will be overwritten.
-func (data *templateData) writeToFile(dest string) error {
+// execute executes the template located at templates/generated.go.tmpl
+// with the given templateData and returns the formatted go code.
+func (data *templateData) execute() ([]byte, error) {
 	tmplAsset, err := assets.Asset("templates/generated.go.tmpl")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	generatedTmpl := template.Must(template.New("generated").Parse(string(tmplAsset)))
+	// Names and sources are quoted with strconv.Quote, since a raw string
+	// literal can't hold backticks, carriage returns, or invalid UTF-8.
+	funcs := template.FuncMap{
+		"quote": strconv.Quote,
+	}
+	generatedTmpl := template.Must(template.New("generated").Funcs(funcs).Parse(string(tmplAsset)))
 	buf := bytes.NewBuffer([]byte{})
 	if err := generatedTmpl.Execute(buf, data); err != nil {
-		return err
-	}
-	formatted, err := format.Source(buf.Bytes())
-	if err != nil {
-		return err
+		return nil, err
 	}
-	prtty.Success.Printf("    created %s", dest)
-	return ioutil.WriteFile(dest, formatted, os.ModePerm)
+	return format.Source(buf.Bytes())
 }
 
 // collectSourceFiles recursively walks through dir and its subdirectories
-// and returns an array of all the source files (files which end in .tmpl).
-func collectSourceFiles(dir string) ([]sourceFile, error) {
+// and returns an array of all the source files (files which have one of the
+// extensions in opts and are not ignored).
+func collectSourceFiles(dir string, opts *buildOptions) ([]sourceFile, error) {
 	sourceFiles := []sourceFile{}
-	if err := collectTemplateFiles(dir, func(name, filename string) error {
+	if err := opts.files.collect(dir, func(name, filename string) error {
 		src, err := ioutil.ReadFile(filename)
 		if err != nil {
 			return err
 		}
-		prtty.Default.Printf("    %s", filename)
+		opts.log.normal.Printf("    %s", filename)
 		sourceFiles = append(sourceFiles, sourceFile{
-			Name: name,
-			Src:  string(src),
+			Name:     name,
+			Filename: filename,
+			Src:      string(src),
 		})
 		return nil
 	}); err != nil {
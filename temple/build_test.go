@@ -6,13 +6,33 @@
 package temple
 
 import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
 const (
-	destFile = "test_files/templates.go"
-	runFile  = "test_files/run.go"
+	destFile      = "test_files/templates.go"
+	runFile       = "test_files/run.go"
+	funcsDestFile = "test_files/funcs_templates.go"
+	funcsRunFile  = "test_files/run_funcs.go"
+	funcsVarName  = "github.com/go-humble/temple/temple/test_files/funcs.FuncMap"
+	// precompileDestFile is shared by the tests which use WithPrecompile.
+	precompileDestFile = "test_files/precompiled_templates.go"
+	precompileRunFile  = "test_files/run_precompile.go"
+	textDestFile       = "test_files/text_templates.go"
+	textRunFile        = "test_files/run_text.go"
+	typedDestFile      = "test_files/typed_templates.go"
+	typedRunFile       = "test_files/run_typed.go"
 )
 
 func TestBuild(t *testing.T) {
@@ -20,6 +40,19 @@ func TestBuild(t *testing.T) {
 	if err := Build("test_files/templates", destFile, "test_files/partials", "test_files/layouts", "main"); err != nil {
 		t.Error(err)
 	}
+	generated, err := ioutil.ReadFile(destFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expectedConst := range []string{
+		`TemplateTodosIndex = "todos/index"`,
+		`PartialTodo        = "partials/todo"`,
+		`LayoutApp          = "layouts/app"`,
+	} {
+		if !strings.Contains(string(generated), expectedConst) {
+			t.Errorf("Expected generated code to contain %s but it did not:\n%s", expectedConst, string(generated))
+		}
+	}
 	// Use go run to run the file together with the run file
 	cmd := exec.Command("go", "run", destFile, runFile)
 	output, err := cmd.CombinedOutput()
@@ -31,3 +64,300 @@ func TestBuild(t *testing.T) {
 		t.Errorf("Output from generated code was not correct.\nExpected %s\nBut got:  %s", expected, string(output))
 	}
 }
+
+func TestBuildWithFuncs(t *testing.T) {
+	// Generate a go source file with build, using a template which calls
+	// a function from the funcs package
+	if err := Build("test_files/funcs_templates", funcsDestFile, "", "", "main", WithFuncs(funcsVarName)); err != nil {
+		t.Fatal(err)
+	}
+	// Use go run to run the file together with the run file
+	cmd := exec.Command("go", "run", funcsDestFile, funcsRunFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "<p>Hello, world!</p>"
+	if string(output) != expected {
+		t.Errorf("Output from generated code was not correct.\nExpected %s\nBut got:  %s", expected, string(output))
+	}
+}
+
+func TestBuildWithUndefinedFunc(t *testing.T) {
+	// Without the funcs option, the greet function is not defined and the
+	// template should fail to compile.
+	if err := Build("test_files/funcs_templates", funcsDestFile, "", "", "main"); err == nil {
+		t.Error("Expected an error when building a template which calls an undefined function but got none")
+	}
+}
+
+func TestBuildPrecompiled(t *testing.T) {
+	// Generate a go source file with build, using the precompile option
+	if err := Build("test_files/templates", precompileDestFile, "test_files/partials", "test_files/layouts", "main", WithPrecompile()); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile(precompileDestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(generated), "{{") {
+		t.Errorf("Expected precompiled code to not contain any template source but it did:\n%s", string(generated))
+	}
+	// Use go run to run the file together with the run file
+	cmd := exec.Command("go", "run", precompileDestFile, runFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "<html><head><title>Todos</title></head><body><ul><li>One</li><li>Two</li><li>Three</li></ul></body></html>"
+	if string(output) != expected {
+		t.Errorf("Output from generated code was not correct.\nExpected %s\nBut got:  %s", expected, string(output))
+	}
+}
+
+func TestBuildPrecompiledNodes(t *testing.T) {
+	// The kitchen template uses every kind of node. Its output should be the
+	// same whether or not it was precompiled.
+	outputs := []string{}
+	for _, options := range [][]BuildOption{nil, {WithPrecompile()}} {
+		if err := Build("test_files/precompile_templates", precompileDestFile, "", "", "main", options...); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command("go", "run", precompileDestFile, precompileRunFile)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %s", err.Error(), string(output))
+		}
+		outputs = append(outputs, string(output))
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Output from precompiled code was not correct.\nExpected %s\nBut got:  %s", outputs[0], outputs[1])
+	}
+}
+
+func TestBuildLazy(t *testing.T) {
+	// The generated code should work the same whether or not it is
+	// precompiled.
+	for _, options := range [][]BuildOption{{WithLazy()}, {WithLazy(), WithPrecompile()}} {
+		if err := Build("test_files/templates", destFile, "test_files/partials", "test_files/layouts", "main", options...); err != nil {
+			t.Fatal(err)
+		}
+		generated, err := ioutil.ReadFile(destFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(generated), "Load = lg.Load") {
+			t.Errorf("Expected generated code to use a LazyGroup but it did not:\n%s", string(generated))
+		}
+		// Use go run to run the file together with the run file
+		cmd := exec.Command("go", "run", destFile, runFile)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Error(err)
+		}
+		expected := "<html><head><title>Todos</title></head><body><ul><li>One</li><li>Two</li><li>Three</li></ul></body></html>"
+		if string(output) != expected {
+			t.Errorf("Output from generated code was not correct.\nExpected %s\nBut got:  %s", expected, string(output))
+		}
+	}
+}
+
+func TestBuildTextMode(t *testing.T) {
+	// Generate a go source file with build, using the text mode. The
+	// output should not be escaped.
+	if err := Build("test_files/text_templates", textDestFile, "test_files/text_partials", "", "main", WithMode("text")); err != nil {
+		t.Fatal(err)
+	}
+	// Use go run to run the file together with the run file
+	cmd := exec.Command("go", "run", textDestFile, textRunFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "Hi Bob & Alice,\n\nYour order of <widgets> has shipped.\n\n-- Temple <temple@example.com>\n"
+	if string(output) != expected {
+		t.Errorf("Output from generated code was not correct.\nExpected %s\nBut got:  %s", expected, string(output))
+	}
+	if err := Build("test_files/text_templates", textDestFile, "test_files/text_partials", "", "main", WithMode("markdown")); err == nil {
+		t.Error("Expected an error in Build for an unknown mode but got none")
+	}
+}
+
+func TestBuildRenderFuncs(t *testing.T) {
+	// Generate a go source file with build, using templates which declare
+	// their data types.
+	if err := Build("test_files/typed_templates", typedDestFile, "test_files/typed_partials", "", "main"); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile(typedDestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFunc := "func RenderTodosIndex(wr io.Writer, data models.TodoList) error"
+	if !strings.Contains(string(generated), expectedFunc) {
+		t.Errorf("Expected generated code to contain %s but it did not:\n%s", expectedFunc, string(generated))
+	}
+	// Use go run to run the file together with the run file, which calls
+	// the render function.
+	cmd := exec.Command("go", "run", typedDestFile, typedRunFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err.Error(), string(output))
+	}
+	for _, expected := range []string{"<h1>Chores by Bob</h1>", "<li>DISHES (done)", "<li>LAUNDRY color=blue blue</li>", "<p>2 todos for Chores</p>"} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("Expected output from generated code to contain %s but got:\n%s", expected, string(output))
+		}
+	}
+	// Templates which do not declare a data type should accept anything.
+	if err := Build("test_files/templates", destFile, "test_files/partials", "test_files/layouts", "main"); err != nil {
+		t.Fatal(err)
+	}
+	generated, err = ioutil.ReadFile(destFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFunc = "func RenderTodosIndex(wr io.Writer, data interface{}) error"
+	if !strings.Contains(string(generated), expectedFunc) {
+		t.Errorf("Expected generated code to contain %s but it did not:\n%s", expectedFunc, string(generated))
+	}
+}
+
+func TestBuildEscapesSources(t *testing.T) {
+	// Each of these sources would break generated code if it were written
+	// inside a raw string literal.
+	sources := map[string]string{
+		"backticks": "<script>var greeting = `Hello, ${name}!`;</script>\n```go\nfmt.Println(`code`)\n```",
+		"crlf":      "<ul>\r\n<li>{{ . }}</li>\r\n</ul>\r\n",
+		"non-utf8":  "caf\xe9 \xff\xfe {{ . }}",
+	}
+	dir, err := ioutil.TempDir("", "temple-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "templates")
+	if err := os.Mkdir(src, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range sources {
+		if err := ioutil.WriteFile(filepath.Join(src, name+".tmpl"), []byte(source), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dest := filepath.Join(dir, "templates.go")
+	if err := Build(src, dest, "", "", "main"); err != nil {
+		t.Fatal(err)
+	}
+	// Parse the generated code and check that the arguments to AddTemplate
+	// match the original sources byte for byte.
+	file, err := parser.ParseFile(token.NewFileSet(), dest, nil, 0)
+	if err != nil {
+		t.Fatalf("Generated code could not be parsed: %s", err.Error())
+	}
+	got := map[string]string{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "AddTemplate" {
+			return true
+		}
+		args := []string{}
+		for _, arg := range call.Args {
+			lit, ok := arg.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				t.Errorf("Expected arguments to AddTemplate to be string literals but got %T", arg)
+				return false
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Errorf("Unexpected error unquoting %s: %s", lit.Value, err.Error())
+				return false
+			}
+			args = append(args, value)
+		}
+		got[args[0]] = args[1]
+		return false
+	})
+	for name, expected := range sources {
+		if got[name] != expected {
+			t.Errorf("Source for %s was not embedded correctly.\nExpected %q\nBut got:  %q", name, expected, got[name])
+		}
+	}
+}
+
+func TestBuildWithConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "templates.go")
+	log := bytes.NewBuffer(nil)
+	result, err := BuildWithConfig(BuildConfig{
+		Src:      "test_files/templates",
+		Dest:     dest,
+		Partials: "test_files/partials",
+		Layouts:  "test_files/layouts",
+		Package:  "main",
+		DryRun:   true,
+		Log:      log,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error in BuildWithConfig: %s", err.Error())
+	}
+	// With DryRun set, nothing should be written to dest.
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Expected BuildWithConfig to not write %s with DryRun set", dest)
+	}
+	expectedFiles := []string{
+		filepath.Join("test_files", "partials", "todo.tmpl"),
+		filepath.Join("test_files", "layouts", "app.tmpl"),
+		filepath.Join("test_files", "templates", "todos", "index.tmpl"),
+	}
+	if !reflect.DeepEqual(result.Files, expectedFiles) {
+		t.Errorf("Files from BuildWithConfig were not correct.\nExpected: %v\nBut got:  %v", expectedFiles, result.Files)
+	}
+	if !bytes.Contains(result.Code, []byte(`TemplateTodosIndex = "todos/index"`)) {
+		t.Errorf("Expected generated code to contain the name constants but got:\n%s", string(result.Code))
+	}
+	// The layout is called by the todos/index template, so nothing is unused.
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings from BuildWithConfig but got: %v", result.Warnings)
+	}
+	if !strings.Contains(log.String(), "--> done!") {
+		t.Errorf("Expected BuildWithConfig to print messages to Log but got:\n%s", log.String())
+	}
+	// Errors are returned just like from Build.
+	_, err = BuildWithConfig(BuildConfig{
+		Src:     "test_files/templates",
+		Dest:    dest,
+		Package: "main",
+		Log:     ioutil.Discard,
+	})
+	if err == nil {
+		t.Fatal("Expected an error in BuildWithConfig for a template which calls a partial that does not exist but got none")
+	}
+	// Without DryRun, the generated code should be written to dest.
+	result, err = BuildWithConfig(BuildConfig{
+		Src:      "test_files/text_templates",
+		Dest:     dest,
+		Partials: "test_files/text_partials",
+		Package:  "main",
+		Mode:     "text",
+		Log:      ioutil.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error in BuildWithConfig: %s", err.Error())
+	}
+	generated, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, result.Code) {
+		t.Error("Expected BuildWithConfig to write the generated code to dest")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+// Check is the function called when you run the check sub-command in
+// the command line tool. It runs all the same checks as Build on the
+// templates in the src directory and the partials and layouts, if they
+// are provided, but doesn't write any files. If dest is not an empty
+// string, Check also makes sure that the file located at dest is exactly
+// the same as the code Build would generate for it with the same
+// arguments, and returns an error if it is missing or out of date. That
+// way, you can catch templates which were changed without running Build
+// again, e.g. in continuous integration. Any options are applied in order,
+// and should be the same as the ones passed to Build.
+func Check(src, dest, partials, layouts, packageName string, options ...BuildOption) error {
+	opts, err := newBuildOptions(options)
+	if err != nil {
+		return err
+	}
+	opts.log.info.Println("--> checking...")
+	opts.print(src, dest, partials, layouts, packageName)
+	dirs := sourceDirGroup{
+		templates: src,
+		partials:  partials,
+		layouts:   layouts,
+	}
+	if _, err := checkCompileTemplates(dirs, opts); err != nil {
+		return err
+	}
+	if dest != "" {
+		if err := checkGeneratedFile(dirs, dest, packageName, opts); err != nil {
+			return err
+		}
+	}
+	opts.log.info.Println("--> done!")
+	return nil
+}
+
+// checkGeneratedFile returns an error if the file located at dest is not
+// exactly the same as the code that Build would write to it.
+func checkGeneratedFile(dirs sourceDirGroup, dest, packageName string, opts *buildOptions) error {
+	data, err := newTemplateData(dirs, dest, packageName, opts)
+	if err != nil {
+		return err
+	}
+	generated, err := data.execute()
+	if err != nil {
+		return err
+	}
+	opts.log.info.Println("--> comparing with existing go code...")
+	existing, err := ioutil.ReadFile(dest)
+	if err != nil {
+		return fmt.Errorf("temple: could not read generated file: %s", err.Error())
+	}
+	if !bytes.Equal(existing, generated) {
+		return fmt.Errorf("temple: %s is out of date. Run temple build to generate it again.", dest)
+	}
+	opts.log.success.Printf("    %s is up to date", dest)
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templates := filepath.Join(dir, "templates")
+	dest := filepath.Join(dir, "templates.go")
+	writeTestFile(t, filepath.Join(templates, "index.tmpl"), "<p>{{ . }}</p>")
+	// Without a dest file, only the templates are checked and nothing is
+	// written.
+	if err := Check(templates, "", "", "", "main"); err != nil {
+		t.Errorf("Unexpected error in Check: %s", err.Error())
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Expected Check to not write %s", dest)
+	}
+	// A missing dest file is out of date.
+	if err := Check(templates, dest, "", "", "main"); err == nil {
+		t.Error("Expected an error in Check for a dest file which does not exist but got none")
+	}
+	if err := Build(templates, dest, "", "", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(templates, dest, "", "", "main"); err != nil {
+		t.Errorf("Unexpected error in Check after Build: %s", err.Error())
+	}
+	// The dest file must be generated with the same options.
+	if err := Check(templates, dest, "", "", "main", WithLazy()); err == nil {
+		t.Error("Expected an error in Check with different options than Build but got none")
+	}
+	// Changing a template makes the dest file out of date.
+	writeTestFile(t, filepath.Join(templates, "index.tmpl"), "<div>{{ . }}</div>")
+	err = Check(templates, dest, "", "", "main")
+	if err == nil {
+		t.Fatal("Expected an error in Check after changing a template but got none")
+	}
+	if !strings.Contains(err.Error(), "out of date") {
+		t.Errorf("Expected error from Check to say the dest file is out of date but got: %s", err.Error())
+	}
+	// Errors in the templates are reported just like by Build.
+	writeTestFile(t, filepath.Join(templates, "index.tmpl"), `{{ template "partials/missing" }}`)
+	if err := Check(templates, "", "", "", "main"); err == nil {
+		t.Error("Expected an error in Check for a template which calls a partial that does not exist but got none")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultConfigFile is the config file which the build command reads
+// when it is run without any arguments.
+const DefaultConfigFile = "temple.json"
+
+// Config declares several targets which can be built together. A Config
+// is typically read from a json file with ReadConfig, e.g.:
+//
+//	{
+//		"targets": [
+//			{
+//				"src": "templates",
+//				"dest": "templates/templates.go",
+//				"partials": "partials",
+//				"layouts": "layouts"
+//			},
+//			{
+//				"src": "emails",
+//				"dest": "emails/emails.go",
+//				"package": "emails",
+//				"mode": "text"
+//			}
+//		]
+//	}
+type Config struct {
+	Targets []Target `json:"targets"`
+}
+
+// Target holds the arguments and options for a single call to Build.
+// Src, Dest, Partials, Layouts, and Package correspond to the arguments
+// of Build, and the rest of the fields correspond to BuildOptions, just
+// like for a BuildConfig.
+type Target struct {
+	Src        string   `json:"src"`
+	Dest       string   `json:"dest"`
+	Partials   string   `json:"partials,omitempty"`
+	Layouts    string   `json:"layouts,omitempty"`
+	Package    string   `json:"package,omitempty"`
+	Funcs      string   `json:"funcs,omitempty"`
+	Precompile bool     `json:"precompile,omitempty"`
+	Lazy       bool     `json:"lazy,omitempty"`
+	Mode       string   `json:"mode,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+	Ignore     []string `json:"ignore,omitempty"`
+	Strict     bool     `json:"strict,omitempty"`
+}
+
+// ReadConfig reads a Config from the json file located at filename. Any
+// relative directories or files in the targets are relative to the
+// directory that filename is in. Every target must have a src and dest,
+// and unknown fields are an error, so that typos are caught early.
+func ReadConfig(filename string) (*Config, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	config := &Config{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("temple: could not read config file %s: %s", filename, err.Error())
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("temple: config file %s does not declare any targets", filename)
+	}
+	dir := filepath.Dir(filename)
+	for i, target := range config.Targets {
+		if target.Src == "" || target.Dest == "" {
+			return nil, fmt.Errorf("temple: target %d in config file %s must have a src and dest", i, filename)
+		}
+		for _, path := range []*string{&target.Src, &target.Dest, &target.Partials, &target.Layouts} {
+			if *path != "" && !filepath.IsAbs(*path) {
+				*path = filepath.Join(dir, *path)
+			}
+		}
+		config.Targets[i] = target
+	}
+	return config, nil
+}
+
+// Build builds each target in order, stopping at and returning the first
+// error.
+func (config *Config) Build() error {
+	for _, target := range config.Targets {
+		if err := target.Build(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build builds the target with BuildWithConfig.
+func (target Target) Build() error {
+	_, err := BuildWithConfig(target.BuildConfig())
+	return err
+}
+
+// Options returns the BuildOptions which correspond to the fields of the
+// target.
+func (target Target) Options() []BuildOption {
+	return target.BuildConfig().options()
+}
+
+// BuildConfig returns the BuildConfig which corresponds to the target.
+func (target Target) BuildConfig() BuildConfig {
+	return BuildConfig{
+		Src:        target.Src,
+		Dest:       target.Dest,
+		Partials:   target.Partials,
+		Layouts:    target.Layouts,
+		Package:    target.Package,
+		Funcs:      target.Funcs,
+		Precompile: target.Precompile,
+		Lazy:       target.Lazy,
+		Mode:       target.Mode,
+		Extensions: target.Extensions,
+		Ignore:     target.Ignore,
+		Strict:     target.Strict,
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "temple.json")
+	writeTestFile(t, filename, `{
+		"targets": [
+			{"src": "templates", "dest": "templates/templates.go", "partials": "partials", "layouts": "/abs/layouts", "lazy": true},
+			{"src": "emails", "dest": "emails/emails.go", "package": "emails", "funcs": "github.com/me/app/helpers.FuncMap", "mode": "text"}
+		]
+	}`)
+	config, err := ReadConfig(filename)
+	if err != nil {
+		t.Fatalf("Unexpected error in ReadConfig: %s", err.Error())
+	}
+	// Relative directories should be relative to the config file.
+	expected := []Target{
+		{
+			Src:      filepath.Join(dir, "templates"),
+			Dest:     filepath.Join(dir, "templates", "templates.go"),
+			Partials: filepath.Join(dir, "partials"),
+			Layouts:  "/abs/layouts",
+			Lazy:     true,
+		},
+		{
+			Src:     filepath.Join(dir, "emails"),
+			Dest:    filepath.Join(dir, "emails", "emails.go"),
+			Package: "emails",
+			Funcs:   "github.com/me/app/helpers.FuncMap",
+			Mode:    "text",
+		},
+	}
+	if !reflect.DeepEqual(config.Targets, expected) {
+		t.Errorf("Targets from ReadConfig were not correct.\nExpected: %+v\nBut got:  %+v", expected, config.Targets)
+	}
+	if got := len(config.Targets[0].Options()); got != 1 {
+		t.Errorf("Expected 1 option for the first target but got %d", got)
+	}
+	if got := len(config.Targets[1].Options()); got != 2 {
+		t.Errorf("Expected 2 options for the second target but got %d", got)
+	}
+	invalid := map[string]string{
+		"no targets":    `{"targets": []}`,
+		"no dest":       `{"targets": [{"src": "templates"}]}`,
+		"unknown field": `{"targets": [{"src": "templates", "dest": "templates.go", "partial": "partials"}]}`,
+		"invalid json":  `{"targets": [`,
+	}
+	for desc, src := range invalid {
+		writeTestFile(t, filename, src)
+		if _, err := ReadConfig(filename); err == nil {
+			t.Errorf("Expected an error in ReadConfig for a config file with %s but got none", desc)
+		}
+	}
+}
+
+func TestConfigBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, "templates", "index.tmpl"), `<p>{{ template "partials/greeting" . }}</p>`)
+	writeTestFile(t, filepath.Join(dir, "partials", "greeting.tmpl"), `Hello, {{ . }}!`)
+	writeTestFile(t, filepath.Join(dir, "emails", "welcome.tmpl"), `Welcome, {{ . }}!`)
+	filename := filepath.Join(dir, "temple.json")
+	writeTestFile(t, filename, `{
+		"targets": [
+			{"src": "templates", "dest": "templates/templates.go", "partials": "partials"},
+			{"src": "emails", "dest": "emails/emails.go", "mode": "text"}
+		]
+	}`)
+	config, err := ReadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Build(); err != nil {
+		t.Fatalf("Unexpected error in Config.Build: %s", err.Error())
+	}
+	// Each target should have been built with its own options.
+	expected := map[string]string{
+		filepath.Join(dir, "templates", "templates.go"): "temple.NewGroup()",
+		filepath.Join(dir, "emails", "emails.go"):       "temple.NewTextGroup()",
+	}
+	for dest, expectedGroup := range expected {
+		generated, err := ioutil.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(generated), expectedGroup) {
+			t.Errorf("Expected %s to contain %s but it did not:\n%s", dest, expectedGroup, string(generated))
+		}
+	}
+}
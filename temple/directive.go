@@ -0,0 +1,68 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"strings"
+	"text/template/parse"
+)
+
+// directivePrefix is the prefix for comments which temple reads
+// directives from, e.g. {{/* temple:layout app */}}.
+const directivePrefix = "temple:"
+
+// builtinFuncNames holds the names of the functions that text/template
+// and html/template define for every template. They are needed when
+// parsing with the parse package directly, which otherwise would not
+// know that they are defined.
+var builtinFuncNames = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or",
+	"print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// parseSource parses src just like text/template does and returns the
+// tree for the template itself under name, as well as the trees for any
+// templates it defines. Unlike text/template, comments are kept in the
+// trees so that directives can be read from them later. Comments are
+// ignored when a template is executed. funcs only needs to hold the names
+// of any functions called in src.
+func parseSource(name, src string, funcs map[string]interface{}) (map[string]*parse.Tree, error) {
+	builtins := map[string]interface{}{}
+	for _, funcName := range builtinFuncNames {
+		builtins[funcName] = stubFunc
+	}
+	trees := map[string]*parse.Tree{}
+	tree := parse.New(name)
+	tree.Mode = parse.ParseComments
+	if _, err := tree.Parse(src, "", "", trees, builtins, funcs); err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// findDirective returns the value of the directive with the given key
+// in tree, or an empty string if there is none. A directive is a comment
+// at the top level of the tree which looks like {{/* temple:key value */}}.
+// Trees which were not parsed with parseSource do not include comments,
+// so they never have any directives.
+func findDirective(tree *parse.Tree, key string) string {
+	if tree == nil || tree.Root == nil {
+		return ""
+	}
+	for _, node := range tree.Root.Nodes {
+		comment, ok := node.(*parse.CommentNode)
+		if !ok {
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimPrefix(comment.Text, "/*"), "*/")
+		fields := strings.Fields(text)
+		if len(fields) == 2 && fields[0] == directivePrefix+key {
+			return fields[1]
+		}
+	}
+	return ""
+}
@@ -6,7 +6,11 @@
 // templates into regular templates, partials, and layouts, associating
 // each template with other templates depending on which category
 // it belongs to. Temple is compatible with gopherjs and can be
-// compiled to javascript and run in a browser.
+// compiled to javascript and run in a browser. The methods which
+// work with the DOM, such as ExecuteEl and AddAllInline, are only
+// available when the js build tag is set, which gopherjs does
+// automatically. They are also available when compiling to
+// WebAssembly, in which case elements are js.Values.
 //
 // Version 0.1.3
 package temple
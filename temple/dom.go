@@ -3,25 +3,127 @@
 // governed by the MIT license, which can be found
 // in the LICENSE file.
 
+//go:build js && !wasm
+// +build js,!wasm
+
+// The methods in this file render templates to and load templates from
+// the DOM, so they are only built when compiling to javascript with
+// gopherjs. That way, programs which only use temple on the server don't
+// depend on the gopherjs DOM bindings. See dom_wasm.go for the versions
+// which are built when compiling to WebAssembly.
+
 package temple
 
 import (
-	"bytes"
 	"honnef.co/go/js/dom"
 )
 
+// WrapElement returns an Element for el. A dom.Element already satisfies
+// Element, so it is returned as is.
+func WrapElement(el dom.Element) Element {
+	return el
+}
+
+// WrapDocument returns a Document for doc.
+func WrapDocument(doc dom.Document) Document {
+	return domDocument{doc}
+}
+
+// WrapNode returns a Node for n.
+func WrapNode(n dom.Node) Node {
+	return domNode{n}
+}
+
+// domNode wraps a dom.Node so that it satisfies Node.
+type domNode struct {
+	dom.Node
+}
+
+func (n domNode) ChildNodes() []Node {
+	nodes := []Node{}
+	for _, child := range n.Node.ChildNodes() {
+		nodes = append(nodes, domNode{child})
+	}
+	return nodes
+}
+
+func (n domNode) ParentNode() Node {
+	return wrapDOMNode(n.Node.ParentNode())
+}
+
+func (n domNode) NextSibling() Node {
+	return wrapDOMNode(n.Node.NextSibling())
+}
+
+// wrapDOMNode returns a Node for n, or nil if n is nil.
+func wrapDOMNode(n dom.Node) Node {
+	if n == nil {
+		return nil
+	}
+	return domNode{n}
+}
+
+func (n domNode) InsertBefore(node, ref Node) {
+	var before dom.Node
+	if ref != nil {
+		before = ref.(domNode).Node
+	}
+	n.Node.InsertBefore(node.(domNode).Node, before)
+}
+
+func (n domNode) RemoveChild(node Node) {
+	n.Node.RemoveChild(node.(domNode).Node)
+}
+
+func (n domNode) Attributes() map[string]string {
+	if el, ok := n.Node.(dom.Element); ok {
+		return el.Attributes()
+	}
+	return map[string]string{}
+}
+
+func (n domNode) GetAttribute(name string) string {
+	if el, ok := n.Node.(dom.Element); ok {
+		return el.GetAttribute(name)
+	}
+	return ""
+}
+
+func (n domNode) SetAttribute(name, value string) {
+	n.Node.(dom.Element).SetAttribute(name, value)
+}
+
+func (n domNode) RemoveAttribute(name string) {
+	n.Node.(dom.Element).RemoveAttribute(name)
+}
+
+func (n domNode) ParseHTML(html string) []Node {
+	// The contents of a template element are parsed without being added
+	// to the document, and it accepts any html, including table rows.
+	template := dom.GetWindow().Document().CreateElement("template")
+	template.SetInnerHTML(html)
+	return domNode{dom.WrapNode(template.Underlying().Get("content"))}.ChildNodes()
+}
+
+// domDocument wraps a dom.Document so that it satisfies Document.
+type domDocument struct {
+	dom.Document
+}
+
+func (doc domDocument) QuerySelectorAll(selector string) []Element {
+	elements := []Element{}
+	for _, el := range doc.Document.QuerySelectorAll(selector) {
+		elements = append(elements, el)
+	}
+	return elements
+}
+
 // ExecuteEl executes an Executor with the given data and then
 // writes the result to  the innerHTML of el. It only works if
 // you have compiled this code to javascript with gopherjs and
 // it is running in a browser.
 func ExecuteEl(e Executor, el dom.Element, data interface{}) error {
-	// TODO: use a buffer pool
-	buf := bytes.NewBuffer([]byte{})
-	if err := e.Execute(buf, data); err != nil {
-		return err
-	}
-	el.SetInnerHTML(buf.String())
-	return nil
+	return ExecuteElement(e, el, data)
 }
 
 // ExecuteEl executes the template with the given data and then
@@ -48,6 +150,69 @@ func (l *Layout) ExecuteEl(el dom.Element, data interface{}) error {
 	return ExecuteEl(l, el, data)
 }
 
+// PatchEl executes an Executor with the given data and then
+// updates the children of el to match the result, keeping all
+// the existing nodes that it can. See PatchElement. It only
+// works if you have compiled this code to javascript with
+// gopherjs and it is running in a browser.
+func PatchEl(e Executor, el dom.Element, data interface{}) error {
+	return PatchElement(e, WrapNode(el), data)
+}
+
+// PatchEl executes the template with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (t *Template) PatchEl(el dom.Element, data interface{}) error {
+	return PatchEl(t, el, data)
+}
+
+// PatchEl executes the partial with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (p *Partial) PatchEl(el dom.Element, data interface{}) error {
+	return PatchEl(p, el, data)
+}
+
+// PatchEl executes the layout with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (l *Layout) PatchEl(el dom.Element, data interface{}) error {
+	return PatchEl(l, el, data)
+}
+
+// InsertEl executes an Executor with the given data and then
+// inserts the result relative to el, depending on mode. It
+// returns the inserted nodes so that event listeners can be
+// attached to them. See InsertElement. It only works if you
+// have compiled this code to javascript with gopherjs and it
+// is running in a browser.
+func InsertEl(e Executor, el dom.Element, mode InsertMode, data interface{}) ([]dom.Node, error) {
+	nodes, err := InsertElement(e, WrapNode(el), mode, data)
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]dom.Node, len(nodes))
+	for i, node := range nodes {
+		inserted[i] = node.(domNode).Node
+	}
+	return inserted, nil
+}
+
+// InsertEl executes the template with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (t *Template) InsertEl(el dom.Element, mode InsertMode, data interface{}) ([]dom.Node, error) {
+	return InsertEl(t, el, mode, data)
+}
+
+// InsertEl executes the partial with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (p *Partial) InsertEl(el dom.Element, mode InsertMode, data interface{}) ([]dom.Node, error) {
+	return InsertEl(p, el, mode, data)
+}
+
+// InsertEl executes the layout with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (l *Layout) InsertEl(el dom.Element, mode InsertMode, data interface{}) ([]dom.Node, error) {
+	return InsertEl(l, el, mode, data)
+}
+
 // AddAllInline scans the DOM for inline templates which
 // must be script tags with the type "text/template". The id property
 // will be used for the name of each template, and the special
@@ -57,29 +222,7 @@ func (l *Layout) ExecuteEl(el dom.Element, data interface{}) error {
 // tag that looks like:
 //   <script type="text/template" id="todo" data-kind="partial">
 func (g *Group) AddAllInline() error {
-	document := dom.GetWindow().Document()
-	elements := document.QuerySelectorAll(`script[type="text/template"]`)
-	for _, el := range elements {
-		switch el.GetAttribute("data-kind") {
-		case "template":
-			if err := g.AddInlineTemplate(el); err != nil {
-				return err
-			}
-		case "partial":
-			if err := g.AddInlinePartial(el); err != nil {
-				return err
-			}
-		case "layout":
-			if err := g.AddInlineLayout(el); err != nil {
-				return err
-			}
-		default:
-			if err := g.AddInlineTemplate(el); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return g.AddAllInlineFrom(WrapDocument(dom.GetWindow().Document()))
 }
 
 // AddInlineTemplate adds the inline template el to the
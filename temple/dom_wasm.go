@@ -0,0 +1,293 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+//go:build js && wasm
+// +build js,wasm
+
+// The methods in this file are the WebAssembly versions of the ones in
+// dom.go. They work the same way, except that elements are js.Values from
+// the builtin syscall/js package instead of dom.Elements.
+
+package temple
+
+import (
+	"syscall/js"
+)
+
+// WrapElement returns an Element for el, which must hold a DOM element.
+func WrapElement(el js.Value) Element {
+	return jsNode{el}
+}
+
+// WrapDocument returns a Document for doc, which must hold a DOM
+// document.
+func WrapDocument(doc js.Value) Document {
+	return jsDocument{doc}
+}
+
+// WrapNode returns a Node for n, which must hold a DOM node.
+func WrapNode(n js.Value) Node {
+	return jsNode{n}
+}
+
+// jsNode wraps a js.Value which holds a DOM node so that it
+// satisfies Node, and also Element if it holds an element.
+type jsNode struct {
+	js.Value
+}
+
+func (n jsNode) ID() string {
+	return n.Get("id").String()
+}
+
+func (n jsNode) InnerHTML() string {
+	return n.Get("innerHTML").String()
+}
+
+func (n jsNode) SetInnerHTML(html string) {
+	n.Set("innerHTML", html)
+}
+
+func (n jsNode) GetAttribute(name string) string {
+	if n.NodeType() != ElementNode {
+		return ""
+	}
+	return stringOrEmpty(n.Call("getAttribute", name))
+}
+
+func (n jsNode) SetAttribute(name, value string) {
+	n.Call("setAttribute", name, value)
+}
+
+func (n jsNode) RemoveAttribute(name string) {
+	n.Call("removeAttribute", name)
+}
+
+func (n jsNode) Attributes() map[string]string {
+	attrs := map[string]string{}
+	if n.NodeType() != ElementNode {
+		return attrs
+	}
+	list := n.Get("attributes")
+	for i := 0; i < list.Length(); i++ {
+		attr := list.Index(i)
+		attrs[attr.Get("name").String()] = attr.Get("value").String()
+	}
+	return attrs
+}
+
+func (n jsNode) NodeType() int {
+	return n.Get("nodeType").Int()
+}
+
+func (n jsNode) NodeName() string {
+	return n.Get("nodeName").String()
+}
+
+func (n jsNode) NodeValue() string {
+	return stringOrEmpty(n.Get("nodeValue"))
+}
+
+func (n jsNode) SetNodeValue(value string) {
+	n.Set("nodeValue", value)
+}
+
+func (n jsNode) ChildNodes() []Node {
+	return wrapNodeList(n.Get("childNodes"))
+}
+
+func (n jsNode) ParentNode() Node {
+	return wrapJSNode(n.Get("parentNode"))
+}
+
+func (n jsNode) NextSibling() Node {
+	return wrapJSNode(n.Get("nextSibling"))
+}
+
+// wrapJSNode returns a Node for v, or nil if v is null or undefined.
+func wrapJSNode(v js.Value) Node {
+	if v.IsNull() || v.IsUndefined() {
+		return nil
+	}
+	return jsNode{v}
+}
+
+func (n jsNode) InsertBefore(node, ref Node) {
+	before := js.Null()
+	if ref != nil {
+		before = ref.(jsNode).Value
+	}
+	n.Call("insertBefore", node.(jsNode).Value, before)
+}
+
+func (n jsNode) RemoveChild(node Node) {
+	n.Call("removeChild", node.(jsNode).Value)
+}
+
+func (n jsNode) ParseHTML(html string) []Node {
+	// The contents of a template element are parsed without being added
+	// to the document, and it accepts any html, including table rows.
+	template := js.Global().Get("document").Call("createElement", "template")
+	template.Set("innerHTML", html)
+	return wrapNodeList(template.Get("content").Get("childNodes"))
+}
+
+// wrapNodeList returns the nodes in list, which must hold a NodeList. The
+// nodes are copied, so that the result doesn't change when they are moved.
+func wrapNodeList(list js.Value) []Node {
+	nodes := make([]Node, list.Length())
+	for i := range nodes {
+		nodes[i] = jsNode{list.Index(i)}
+	}
+	return nodes
+}
+
+// stringOrEmpty returns the string held by v, or an empty string if v is
+// null or undefined.
+func stringOrEmpty(v js.Value) string {
+	if v.IsNull() || v.IsUndefined() {
+		return ""
+	}
+	return v.String()
+}
+
+// jsDocument wraps a js.Value which holds a DOM document so that it
+// satisfies Document.
+type jsDocument struct {
+	js.Value
+}
+
+func (doc jsDocument) QuerySelectorAll(selector string) []Element {
+	nodes := doc.Call("querySelectorAll", selector)
+	elements := make([]Element, nodes.Length())
+	for i := range elements {
+		elements[i] = jsNode{nodes.Index(i)}
+	}
+	return elements
+}
+
+// ExecuteEl executes an Executor with the given data and then
+// writes the result to the innerHTML of el. It only works if
+// you have compiled this code to WebAssembly and it is running
+// in a browser.
+func ExecuteEl(e Executor, el js.Value, data interface{}) error {
+	return ExecuteElement(e, jsNode{el}, data)
+}
+
+// ExecuteEl executes the template with the given data and then
+// writes the result to the innerHTML of el. It only works if
+// you have compiled this code to WebAssembly and it is running
+// in a browser.
+func (t *Template) ExecuteEl(el js.Value, data interface{}) error {
+	return ExecuteEl(t, el, data)
+}
+
+// ExecuteEl executes the partial with the given data and then
+// writes the result to the innerHTML of el. It only works if
+// you have compiled this code to WebAssembly and it is running
+// in a browser.
+func (p *Partial) ExecuteEl(el js.Value, data interface{}) error {
+	return ExecuteEl(p, el, data)
+}
+
+// ExecuteEl executes the layout with the given data and then
+// writes the result to the innerHTML of el. It only works if
+// you have compiled this code to WebAssembly and it is running
+// in a browser.
+func (l *Layout) ExecuteEl(el js.Value, data interface{}) error {
+	return ExecuteEl(l, el, data)
+}
+
+// PatchEl executes an Executor with the given data and then
+// updates the children of el to match the result, keeping all
+// the existing nodes that it can. See PatchElement. It only
+// works if you have compiled this code to WebAssembly and it is
+// running in a browser.
+func PatchEl(e Executor, el js.Value, data interface{}) error {
+	return PatchElement(e, jsNode{el}, data)
+}
+
+// PatchEl executes the template with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (t *Template) PatchEl(el js.Value, data interface{}) error {
+	return PatchEl(t, el, data)
+}
+
+// PatchEl executes the partial with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (p *Partial) PatchEl(el js.Value, data interface{}) error {
+	return PatchEl(p, el, data)
+}
+
+// PatchEl executes the layout with the given data and then
+// updates the children of el to match the result. See PatchElement.
+func (l *Layout) PatchEl(el js.Value, data interface{}) error {
+	return PatchEl(l, el, data)
+}
+
+// InsertEl executes an Executor with the given data and then
+// inserts the result relative to el, depending on mode. It
+// returns the inserted nodes so that event listeners can be
+// attached to them. See InsertElement. It only works if you
+// have compiled this code to WebAssembly and it is running in
+// a browser.
+func InsertEl(e Executor, el js.Value, mode InsertMode, data interface{}) ([]js.Value, error) {
+	nodes, err := InsertElement(e, jsNode{el}, mode, data)
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]js.Value, len(nodes))
+	for i, node := range nodes {
+		inserted[i] = node.(jsNode).Value
+	}
+	return inserted, nil
+}
+
+// InsertEl executes the template with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (t *Template) InsertEl(el js.Value, mode InsertMode, data interface{}) ([]js.Value, error) {
+	return InsertEl(t, el, mode, data)
+}
+
+// InsertEl executes the partial with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (p *Partial) InsertEl(el js.Value, mode InsertMode, data interface{}) ([]js.Value, error) {
+	return InsertEl(p, el, mode, data)
+}
+
+// InsertEl executes the layout with the given data and then
+// inserts the result relative to el. See InsertElement.
+func (l *Layout) InsertEl(el js.Value, mode InsertMode, data interface{}) ([]js.Value, error) {
+	return InsertEl(l, el, mode, data)
+}
+
+// AddAllInline scans the DOM for inline templates which
+// must be script tags with the type "text/template". It works
+// exactly like the gopherjs version, including the special
+// "data-kind" property.
+func (g *Group) AddAllInline() error {
+	return g.AddAllInlineFrom(jsDocument{js.Global().Get("document")})
+}
+
+// AddInlineTemplate adds the inline template el to the
+// group as a regular template. It uses the id property as
+// the template name and the innerHTML as the template source.
+func (g *Group) AddInlineTemplate(el js.Value) error {
+	return g.AddTemplate(jsNode{el}.ID(), jsNode{el}.InnerHTML())
+}
+
+// AddInlinePartial adds the inline template el to the
+// group as a partial. It uses the id property as the template
+// name and the innerHTML as the template source.
+func (g *Group) AddInlinePartial(el js.Value) error {
+	return g.AddPartial(jsNode{el}.ID(), jsNode{el}.InnerHTML())
+}
+
+// AddInlineLayout adds the inline template el to the
+// group as a layout. It uses the id property as the template
+// name and the innerHTML as the template source.
+func (g *Group) AddInlineLayout(el js.Value) error {
+	return g.AddLayout(jsNode{el}.ID(), jsNode{el}.InnerHTML())
+}
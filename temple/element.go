@@ -0,0 +1,90 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+)
+
+// Element is an element in the DOM. It is the small part of the DOM that
+// temple depends on, so that rendering to the DOM and loading inline
+// templates work the same way with gopherjs, with WebAssembly, and in
+// tests which don't run in a browser at all. A dom.Element from the
+// gopherjs DOM bindings satisfies Element, and WrapElement returns an
+// Element for the current backend. The fakedom package provides an
+// in-memory implementation.
+type Element interface {
+	// ID returns the id property of the element.
+	ID() string
+	// InnerHTML returns the html inside of the element.
+	InnerHTML() string
+	// SetInnerHTML replaces the contents of the element with html.
+	SetInnerHTML(html string)
+	// GetAttribute returns the value of the attribute with the given name,
+	// or an empty string if the element does not have it.
+	GetAttribute(name string) string
+}
+
+// Document is a DOM document which holds inline templates. WrapDocument
+// returns a Document for the current backend.
+type Document interface {
+	// QuerySelectorAll returns all the elements in the document which
+	// match the given css selector, in document order.
+	QuerySelectorAll(selector string) []Element
+}
+
+// inlineTemplatesSelector selects the script tags which hold inline
+// templates.
+const inlineTemplatesSelector = `script[type="text/template"]`
+
+// ExecuteElement executes an Executor with the given data and then
+// writes the result to the innerHTML of el. It works just like
+// ExecuteEl, but accepts any Element.
+func ExecuteElement(e Executor, el Element, data interface{}) error {
+	html, err := executeToString(e, data)
+	if err != nil {
+		return err
+	}
+	el.SetInnerHTML(html)
+	return nil
+}
+
+// AddAllInlineFrom scans doc for inline templates and adds them to the
+// group. It works just like AddAllInline, but accepts any Document.
+func (g *Group) AddAllInlineFrom(doc Document) error {
+	for _, el := range doc.QuerySelectorAll(inlineTemplatesSelector) {
+		if err := g.addInline(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addInline adds the inline template el to the group as a regular
+// template, partial, or layout, depending on its data-kind attribute. If
+// there is no data-kind attribute, or it is not recognized, el is added as
+// a regular template.
+func (g *Group) addInline(el Element) error {
+	switch el.GetAttribute("data-kind") {
+	case "partial":
+		return g.AddPartial(el.ID(), el.InnerHTML())
+	case "layout":
+		return g.AddLayout(el.ID(), el.InnerHTML())
+	default:
+		return g.AddTemplate(el.ID(), el.InnerHTML())
+	}
+}
+
+// executeToString executes e with the given data and returns the result
+// as a string.
+func executeToString(e Executor, data interface{}) (string, error) {
+	// TODO: use a buffer pool
+	buf := bytes.NewBuffer([]byte{})
+	if err := e.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestBrowserBuilds checks that the package compiles for each of the
+// browser backends, since their files are not built by go test.
+func TestBrowserBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping browser builds in short mode")
+	}
+	t.Run("wasm", func(t *testing.T) {
+		cmd := exec.Command("go", "vet", ".")
+		cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Package does not compile for WebAssembly: %s\n%s", err.Error(), string(output))
+		}
+	})
+	t.Run("gopherjs", func(t *testing.T) {
+		if _, err := exec.LookPath("gopherjs"); err != nil {
+			t.Skip("Skipping gopherjs build because gopherjs is not installed")
+		}
+		cmd := exec.Command("gopherjs", "build", "-o", os.DevNull, ".")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Package does not compile with gopherjs: %s\n%s", err.Error(), string(output))
+		}
+	})
+}
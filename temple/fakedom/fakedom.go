@@ -0,0 +1,409 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// Package fakedom is an in-memory implementation of the parts of the DOM
+// that temple depends on. It lets code which renders templates to the DOM
+// or loads inline templates from it be tested with go test, without a
+// browser. Html is parsed leniently, similar to how a browser would parse
+// it, but only a small subset of css selectors is supported.
+package fakedom
+
+import (
+	"html"
+	"strings"
+
+	"github.com/go-humble/temple/temple"
+)
+
+// NodeType is the type of a Node.
+type NodeType int
+
+const (
+	// ElementNode is the type of an element, e.g. <div>.
+	ElementNode NodeType = 1
+	// TextNode is the type of the text inside of an element.
+	TextNode NodeType = 3
+	// CommentNode is the type of a comment, e.g. <!-- comment -->.
+	CommentNode NodeType = 8
+	// DocumentNode is the type of the root node of a Document.
+	DocumentNode NodeType = 9
+)
+
+// Attr is an attribute of an element.
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Node is a node in a Document. It satisfies temple.Node, and an element
+// Node also satisfies temple.Element.
+type Node struct {
+	Type NodeType
+	// Tag is the lowercase tag name of an element.
+	Tag string
+	// Attrs holds the attributes of an element, in order.
+	Attrs []Attr
+	// Data is the text of a text node or comment.
+	Data     string
+	Parent   *Node
+	Children []*Node
+}
+
+// Document is an in-memory DOM document. It satisfies temple.Document.
+type Document struct {
+	Root *Node
+}
+
+// NewDocument parses src and returns a Document which holds the result.
+func NewDocument(src string) *Document {
+	root := &Node{Type: DocumentNode}
+	root.setChildren(Parse(src))
+	return &Document{Root: root}
+}
+
+// QuerySelectorAll returns all the elements in the document which match
+// selector, in document order. It panics if selector is not supported.
+func (doc *Document) QuerySelectorAll(selector string) []temple.Element {
+	elements := []temple.Element{}
+	for _, el := range doc.Root.QuerySelectorAll(selector) {
+		elements = append(elements, el)
+	}
+	return elements
+}
+
+// QuerySelector returns the first element in the document which matches
+// selector, or nil if there is none.
+func (doc *Document) QuerySelector(selector string) *Node {
+	return doc.Root.QuerySelector(selector)
+}
+
+// GetElementByID returns the element in the document with the given id,
+// or nil if there is none.
+func (doc *Document) GetElementByID(id string) *Node {
+	var found *Node
+	doc.Root.walk(func(n *Node) bool {
+		if n.Type == ElementNode && n.ID() == id {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
+
+// ID returns the id attribute of the element.
+func (n *Node) ID() string {
+	return n.GetAttribute("id")
+}
+
+// GetAttribute returns the value of the attribute with the given name, or
+// an empty string if there is none.
+func (n *Node) GetAttribute(name string) string {
+	value, _ := n.attr(name)
+	return value
+}
+
+// HasAttribute returns true if the element has an attribute with the
+// given name.
+func (n *Node) HasAttribute(name string) bool {
+	_, found := n.attr(name)
+	return found
+}
+
+func (n *Node) attr(name string) (string, bool) {
+	name = strings.ToLower(name)
+	for _, attr := range n.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttribute sets the value of the attribute with the given name, adding
+// it if the element does not have it yet.
+func (n *Node) SetAttribute(name, value string) {
+	name = strings.ToLower(name)
+	for i, attr := range n.Attrs {
+		if attr.Name == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, Attr{Name: name, Value: value})
+}
+
+// RemoveAttribute removes the attribute with the given name, if the
+// element has it.
+func (n *Node) RemoveAttribute(name string) {
+	name = strings.ToLower(name)
+	for i, attr := range n.Attrs {
+		if attr.Name == name {
+			n.Attrs = append(n.Attrs[:i], n.Attrs[i+1:]...)
+			return
+		}
+	}
+}
+
+// InnerHTML returns the html for the children of n.
+func (n *Node) InnerHTML() string {
+	buf := &strings.Builder{}
+	for _, child := range n.Children {
+		child.render(buf, n.isRawText())
+	}
+	return buf.String()
+}
+
+// SetInnerHTML parses src and replaces the children of n with the result.
+func (n *Node) SetInnerHTML(src string) {
+	n.setChildren(n.parseContents(src))
+}
+
+// OuterHTML returns the html for n, including its children.
+func (n *Node) OuterHTML() string {
+	buf := &strings.Builder{}
+	n.render(buf, false)
+	return buf.String()
+}
+
+// TextContent returns the text of n and all of its descendants.
+func (n *Node) TextContent() string {
+	if n.Type == TextNode || n.Type == CommentNode {
+		return n.Data
+	}
+	buf := &strings.Builder{}
+	n.walk(func(d *Node) bool {
+		if d.Type == TextNode {
+			buf.WriteString(d.Data)
+		}
+		return true
+	})
+	return buf.String()
+}
+
+// QuerySelectorAll returns all the descendants of n which match selector,
+// in document order. It panics if selector is not supported.
+func (n *Node) QuerySelectorAll(selector string) []*Node {
+	group := parseSelectorGroup(selector)
+	found := []*Node{}
+	n.walk(func(d *Node) bool {
+		if d != n && d.Type == ElementNode && group.match(d, n) {
+			found = append(found, d)
+		}
+		return true
+	})
+	return found
+}
+
+// QuerySelector returns the first descendant of n which matches selector,
+// or nil if there is none.
+func (n *Node) QuerySelector(selector string) *Node {
+	if found := n.QuerySelectorAll(selector); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// NodeType returns the type of n as an int, for temple.Node.
+func (n *Node) NodeType() int {
+	return int(n.Type)
+}
+
+// NodeName returns the uppercase tag name of an element, or "#text",
+// "#comment", or "#document" for other nodes, just like a browser.
+func (n *Node) NodeName() string {
+	switch n.Type {
+	case TextNode:
+		return "#text"
+	case CommentNode:
+		return "#comment"
+	case DocumentNode:
+		return "#document"
+	}
+	return strings.ToUpper(n.Tag)
+}
+
+// NodeValue returns the text of a text node or comment.
+func (n *Node) NodeValue() string {
+	return n.Data
+}
+
+// SetNodeValue sets the text of a text node or comment.
+func (n *Node) SetNodeValue(value string) {
+	n.Data = value
+}
+
+// ChildNodes returns the children of n.
+func (n *Node) ChildNodes() []temple.Node {
+	nodes := make([]temple.Node, len(n.Children))
+	for i, child := range n.Children {
+		nodes[i] = child
+	}
+	return nodes
+}
+
+// ParentNode returns the parent of n, or nil if it has none.
+func (n *Node) ParentNode() temple.Node {
+	if n.Parent == nil {
+		return nil
+	}
+	return n.Parent
+}
+
+// NextSibling returns the node right after n, or nil if there is none.
+func (n *Node) NextSibling() temple.Node {
+	if n.Parent == nil {
+		return nil
+	}
+	siblings := n.Parent.Children
+	if i := n.Parent.indexOf(n); i+1 < len(siblings) {
+		return siblings[i+1]
+	}
+	return nil
+}
+
+// Attributes returns the attributes of an element.
+func (n *Node) Attributes() map[string]string {
+	attrs := map[string]string{}
+	for _, attr := range n.Attrs {
+		attrs[attr.Name] = attr.Value
+	}
+	return attrs
+}
+
+// InsertBefore inserts node, which must be a *Node, as a child of n right
+// before ref, or as the last child if ref is nil. If node already has a
+// parent, it is moved. It panics if ref is not a child of n.
+func (n *Node) InsertBefore(node, ref temple.Node) {
+	child := node.(*Node)
+	if child.Parent != nil {
+		child.Parent.RemoveChild(child)
+	}
+	i := len(n.Children)
+	if ref != nil {
+		i = n.indexOf(ref.(*Node))
+	}
+	n.Children = append(n.Children, nil)
+	copy(n.Children[i+1:], n.Children[i:])
+	n.Children[i] = child
+	child.Parent = n
+}
+
+// AppendChild adds node as the last child of n. If node already has a
+// parent, it is moved.
+func (n *Node) AppendChild(node *Node) {
+	n.InsertBefore(node, nil)
+}
+
+// RemoveChild removes node, which must be a child of n.
+func (n *Node) RemoveChild(node temple.Node) {
+	child := node.(*Node)
+	i := n.indexOf(child)
+	n.Children = append(n.Children[:i], n.Children[i+1:]...)
+	child.Parent = nil
+}
+
+// indexOf returns the index of child in the children of n. It panics if
+// child is not a child of n, just like a browser throws an exception.
+func (n *Node) indexOf(child *Node) int {
+	for i, c := range n.Children {
+		if c == child {
+			return i
+		}
+	}
+	panic("fakedom: the node is not a child of this node")
+}
+
+// ParseHTML parses src as the contents of n and returns the resulting
+// nodes, without changing n.
+func (n *Node) ParseHTML(src string) []temple.Node {
+	parsed := n.parseContents(src)
+	nodes := make([]temple.Node, len(parsed))
+	for i, node := range parsed {
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// parseContents parses src as the contents of n.
+func (n *Node) parseContents(src string) []*Node {
+	if n.isRawText() {
+		return []*Node{{Type: TextNode, Data: src}}
+	}
+	return Parse(src)
+}
+
+// setChildren replaces the children of n with children.
+func (n *Node) setChildren(children []*Node) {
+	for _, child := range n.Children {
+		child.Parent = nil
+	}
+	n.Children = children
+	for _, child := range children {
+		child.Parent = n
+	}
+}
+
+// walk calls f for n and each of its descendants in document order, until
+// f returns false.
+func (n *Node) walk(f func(*Node) bool) bool {
+	if !f(n) {
+		return false
+	}
+	for _, child := range n.Children {
+		if !child.walk(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRawText returns true if the contents of n are not parsed as html.
+func (n *Node) isRawText() bool {
+	return n.Type == ElementNode && rawTextTags[n.Tag]
+}
+
+// render writes the html for n to buf. If raw is true, text is written
+// without being escaped.
+func (n *Node) render(buf *strings.Builder, raw bool) {
+	switch n.Type {
+	case TextNode:
+		if raw {
+			buf.WriteString(n.Data)
+		} else {
+			buf.WriteString(escapeText(n.Data))
+		}
+	case CommentNode:
+		buf.WriteString("<!--" + n.Data + "-->")
+	case DocumentNode:
+		buf.WriteString(n.InnerHTML())
+	case ElementNode:
+		buf.WriteString("<" + n.Tag)
+		for _, attr := range n.Attrs {
+			buf.WriteString(" " + attr.Name + `="` + escapeAttr(attr.Value) + `"`)
+		}
+		buf.WriteString(">")
+		if voidTags[n.Tag] {
+			return
+		}
+		buf.WriteString(n.InnerHTML())
+		buf.WriteString("</" + n.Tag + ">")
+	}
+}
+
+// escapeText escapes text the same way that browsers do for innerHTML.
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\u00a0", "&nbsp;").Replace(s)
+}
+
+// escapeAttr escapes an attribute value the same way that browsers do for
+// innerHTML.
+func escapeAttr(s string) string {
+	return strings.NewReplacer("&", "&amp;", `"`, "&quot;", "\u00a0", "&nbsp;").Replace(s)
+}
+
+// unescape decodes the character references in s.
+func unescape(s string) string {
+	return html.UnescapeString(s)
+}
@@ -0,0 +1,169 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package fakedom
+
+import (
+	"testing"
+
+	"github.com/go-humble/temple/temple"
+)
+
+// Make sure that the fake DOM satisfies the interfaces temple depends on.
+var (
+	_ temple.Element  = &Node{}
+	_ temple.Node     = &Node{}
+	_ temple.Document = &Document{}
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		src      string
+		expected string
+	}{
+		{
+			src:      `<div id="a" class='b c'>Hello, <b>world</b>!</div>`,
+			expected: `<div id="a" class="b c">Hello, <b>world</b>!</div>`,
+		},
+		{
+			src:      `<UL><li>one<li>two</UL>`,
+			expected: `<ul><li>one</li><li>two</li></ul>`,
+		},
+		{
+			src:      `<ul><li>a<ul><li>b<li>c</ul><li>d</ul>`,
+			expected: `<ul><li>a<ul><li>b</li><li>c</li></ul></li><li>d</li></ul>`,
+		},
+		{
+			src:      `<p>one<p>two<div>three</div>`,
+			expected: `<p>one</p><p>two</p><div>three</div>`,
+		},
+		{
+			src:      `<select><option>a<option selected>b</select>`,
+			expected: `<select><option>a</option><option selected="">b</option></select>`,
+		},
+		{
+			src:      `<table><tr><th>a<td>b<tr><td>c</table>`,
+			expected: `<table><tr><th>a</th><td>b</td></tr><tr><td>c</td></tr></table>`,
+		},
+		{
+			src:      `<input type=text disabled><br/><img src="a.png" />`,
+			expected: `<input type="text" disabled=""><br><img src="a.png">`,
+		},
+		{
+			src:      `<p>&lt;b&gt; &amp; &#39;quotes&#39; &quot;</p></span>`,
+			expected: `<p>&lt;b&gt; &amp; 'quotes' "</p>`,
+		},
+		{
+			src:      `<script type="text/template" id="t"><li>{{ .Name }}</li></script>`,
+			expected: `<script type="text/template" id="t"><li>{{ .Name }}</li></script>`,
+		},
+		{
+			src:      `<!-- comment --><a title="&quot;x&quot;">`,
+			expected: `<!-- comment --><a title="&quot;x&quot;"></a>`,
+		},
+	}
+	for _, tc := range testCases {
+		doc := NewDocument(tc.src)
+		if got := doc.Root.InnerHTML(); got != tc.expected {
+			t.Errorf("Html for %s was not correct.\nExpected: %s\nBut got:  %s", tc.src, tc.expected, got)
+		}
+	}
+}
+
+func TestQuerySelectorAll(t *testing.T) {
+	doc := NewDocument(`
+<div id="todos" class="list">
+	<ul>
+		<li class="todo done" data-id="1">one</li>
+		<li class="todo" data-id="2">two</li>
+	</ul>
+</div>
+<li class="todo">three</li>
+<script type="text/template" id="todo"></script>
+<script type='text/javascript'></script>`)
+	testCases := []struct {
+		selector string
+		expected []string
+	}{
+		{"li", []string{"one", "two", "three"}},
+		{"*.todo", []string{"one", "two", "three"}},
+		{".todo.done", []string{"one"}},
+		{"#todos li", []string{"one", "two"}},
+		{"div.list ul .todo", []string{"one", "two"}},
+		{`li[data-id="2"]`, []string{"two"}},
+		{"[data-id]", []string{"one", "two"}},
+		{"li.done, script#todo", []string{"one", ""}},
+	}
+	for _, tc := range testCases {
+		got := []string{}
+		for _, el := range doc.Root.QuerySelectorAll(tc.selector) {
+			got = append(got, el.TextContent())
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Wrong elements for %s. Expected %v but got %v", tc.selector, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("Wrong elements for %s. Expected %v but got %v", tc.selector, tc.expected, got)
+				break
+			}
+		}
+	}
+	if got := doc.QuerySelectorAll(`script[type="text/template"]`); len(got) != 1 || got[0].ID() != "todo" {
+		t.Errorf("Expected to find the inline template but got %v", got)
+	}
+	if got := doc.GetElementByID("todos"); got == nil || got.Tag != "div" {
+		t.Errorf("Expected GetElementByID to return the div but got %v", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected QuerySelectorAll to panic for an unsupported selector")
+		}
+	}()
+	doc.QuerySelectorAll("li:first-child")
+}
+
+func TestAddAllInlineFrom(t *testing.T) {
+	doc := NewDocument(`
+<script type="text/template" id="todo" data-kind="partial"><li>{{ . }}</li></script>
+<script type="text/template" id="app" data-kind="layout"><ul>{{ template "content" . }}</ul></script>
+<script type="text/template" id="todos" data-kind="template">{{ define "content" }}{{ range . }}{{ template "partials/todo" . }}{{ end }}{{ end }}{{ template "layouts/app" . }}</script>
+<script type="text/template" id="about">About</script>
+<script type="text/template" id="other" data-kind="other">Other</script>
+<script type="text/javascript" id="script">var x = 1 < 2;</script>
+<div id="list"><p>Loading...</p></div>`)
+	g := temple.NewGroup()
+	if err := g.AddAllInlineFrom(doc); err != nil {
+		t.Fatalf("Unexpected error in AddAllInlineFrom: %s", err.Error())
+	}
+	if _, err := g.GetPartial("todo"); err != nil {
+		t.Errorf("Expected todo to be added as a partial: %s", err.Error())
+	}
+	if _, err := g.GetLayout("app"); err != nil {
+		t.Errorf("Expected app to be added as a layout: %s", err.Error())
+	}
+	for _, name := range []string{"todos", "about", "other"} {
+		if _, err := g.GetTemplate(name); err != nil {
+			t.Errorf("Expected %s to be added as a regular template: %s", name, err.Error())
+		}
+	}
+	if _, err := g.GetTemplate("script"); err == nil {
+		t.Error("Expected script tags which are not templates to be skipped")
+	}
+	list := doc.GetElementByID("list")
+	if err := temple.ExecuteElement(g.MustGetTemplate("todos"), list, []string{"a", "<b>"}); err != nil {
+		t.Fatalf("Unexpected error in ExecuteElement: %s", err.Error())
+	}
+	if expected, got := "<ul><li>a</li><li>&lt;b&gt;</li></ul>", list.InnerHTML(); got != expected {
+		t.Errorf("innerHTML was not correct after ExecuteElement.\nExpected: %s\nBut got:  %s", expected, got)
+	}
+	if items := list.QuerySelectorAll("ul li"); len(items) != 2 || items[1].TextContent() != "<b>" {
+		t.Errorf("Expected the rendered html to be parsed into elements but got %s", list.OuterHTML())
+	}
+	if err := temple.ExecuteElement(g.MustGetTemplate("todos"), list, struct{}{}); err == nil {
+		t.Error("Expected an error in ExecuteElement when the template can't be executed")
+	}
+}
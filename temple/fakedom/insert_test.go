@@ -0,0 +1,103 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package fakedom
+
+import (
+	"io"
+	"testing"
+
+	"github.com/go-humble/temple/temple"
+)
+
+func TestInsertElement(t *testing.T) {
+	g := temple.NewGroup()
+	if err := g.AddTemplate("items", `{{ range . }}<li>{{ . }}</li>{{ end }}`); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := g.MustGetTemplate("items")
+	const initial = `<ul id="list"><li>a</li><li>b</li></ul>`
+	testCases := []struct {
+		mode     temple.InsertMode
+		expected string
+	}{
+		{temple.Append, `<ul id="list"><li>a</li><li>b</li><li>x</li><li>y</li></ul>`},
+		{temple.Prepend, `<ul id="list"><li>x</li><li>y</li><li>a</li><li>b</li></ul>`},
+		{temple.Replace, `<li>x</li><li>y</li>`},
+		{temple.Before, `<li>x</li><li>y</li><ul id="list"><li>a</li><li>b</li></ul>`},
+		{temple.After, `<ul id="list"><li>a</li><li>b</li></ul><li>x</li><li>y</li>`},
+	}
+	for _, tc := range testCases {
+		doc := NewDocument(initial + "<p>end</p>")
+		list := doc.GetElementByID("list")
+		nodes, err := temple.InsertElement(tmpl, list, tc.mode, []string{"x", "y"})
+		if err != nil {
+			t.Errorf("Unexpected error in InsertElement with mode %s: %s", tc.mode, err.Error())
+			continue
+		}
+		if expected, got := tc.expected+"<p>end</p>", doc.Root.InnerHTML(); got != expected {
+			t.Errorf("Result of InsertElement with mode %s was not correct.\nExpected: %s\nBut got:  %s", tc.mode, expected, got)
+		}
+		// The returned nodes should be the ones which were inserted, so that
+		// listeners can be attached to them.
+		if len(nodes) != 2 {
+			t.Errorf("Expected InsertElement with mode %s to return 2 nodes but got %d", tc.mode, len(nodes))
+			continue
+		}
+		for i, text := range []string{"x", "y"} {
+			node := nodes[i].(*Node)
+			if node.TextContent() != text || node.Parent == nil || node.ParentNode() == nil {
+				t.Errorf("Expected InsertElement with mode %s to return the inserted nodes but got %s", tc.mode, node.OuterHTML())
+			}
+		}
+		if tc.mode == temple.Replace && list.Parent != nil {
+			t.Error("Expected the element to be removed with mode Replace")
+		}
+	}
+}
+
+func TestInsertElementErrors(t *testing.T) {
+	g := temple.NewGroup()
+	if err := g.AddTemplate("item", `<li>{{ .Title }}</li>`); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := g.MustGetTemplate("item")
+	doc := NewDocument(`<ul id="list"></ul>`)
+	list := doc.GetElementByID("list")
+	if _, err := temple.InsertElement(tmpl, list, temple.Append, 42); err == nil {
+		t.Error("Expected an error in InsertElement when the template can't be executed")
+	}
+	if list.InnerHTML() != "" {
+		t.Errorf("Expected InsertElement to not change the element when there is an error but got %s", list.OuterHTML())
+	}
+	detached := Parse(`<div></div>`)[0]
+	for _, mode := range []temple.InsertMode{temple.Replace, temple.Before, temple.After} {
+		if _, err := temple.InsertElement(tmpl, detached, mode, map[string]string{"Title": "x"}); err == nil {
+			t.Errorf("Expected an error in InsertElement with mode %s for an element with no parent", mode)
+		}
+	}
+	if _, err := temple.InsertElement(tmpl, list, temple.InsertMode(42), map[string]string{"Title": "x"}); err == nil {
+		t.Error("Expected an error in InsertElement for an unknown mode")
+	}
+	// The mode is checked before the template is executed.
+	executor := &countingExecutor{}
+	if _, err := temple.InsertElement(executor, list, temple.InsertMode(42), nil); err == nil {
+		t.Error("Expected an error in InsertElement for an unknown mode")
+	}
+	if executor.count != 0 {
+		t.Errorf("Expected InsertElement to not execute the template for an unknown mode but it was executed %d times", executor.count)
+	}
+}
+
+// countingExecutor is a temple.Executor which counts how many times it is
+// executed.
+type countingExecutor struct {
+	count int
+}
+
+func (e *countingExecutor) Execute(wr io.Writer, data interface{}) error {
+	e.count++
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package fakedom
+
+import (
+	"strings"
+)
+
+// voidTags holds the tags of elements which never have any children and
+// don't have an end tag.
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextTags holds the tags of elements whose contents are text and are
+// not parsed as html, e.g. inline templates in script tags.
+var rawTextTags = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+}
+
+// impliedEnd describes the elements which are closed by a start tag
+// without an end tag of their own, e.g. an open <li> when another <li>
+// starts.
+type impliedEnd struct {
+	// closes holds the tags of the open elements which are closed.
+	closes map[string]bool
+	// scope holds the tags of the elements which the search for an open
+	// element to close stops at, e.g. a nested list for <li>.
+	scope map[string]bool
+}
+
+// impliedEnds holds the implied end tags for each start tag which has
+// them. It covers the common cases from the html spec, but not all of the
+// rules that browsers follow, e.g. browsers also add a tbody to tables and
+// fix misnested formatting elements, but Parse does not.
+var impliedEnds = map[string]impliedEnd{
+	"li":     {closes: tagSet("li"), scope: tagSet("ul", "ol")},
+	"option": {closes: tagSet("option"), scope: tagSet("select", "datalist", "optgroup")},
+	"tr":     {closes: tagSet("tr", "td", "th"), scope: tagSet("table", "thead", "tbody", "tfoot")},
+	"td":     {closes: tagSet("td", "th"), scope: tagSet("tr", "table")},
+	"th":     {closes: tagSet("td", "th"), scope: tagSet("tr", "table")},
+}
+
+func init() {
+	// Block elements, including p itself, close an open p.
+	closesP := impliedEnd{
+		closes: tagSet("p"),
+		scope:  tagSet("button", "table", "td", "th", "caption", "object", "template"),
+	}
+	for _, tag := range []string{
+		"address", "article", "aside", "blockquote", "details", "div", "dl",
+		"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3",
+		"h4", "h5", "h6", "header", "hr", "main", "menu", "nav", "ol", "p",
+		"pre", "section", "table", "ul",
+	} {
+		impliedEnds[tag] = closesP
+	}
+}
+
+func tagSet(tags ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return set
+}
+
+// Parse parses src as a fragment of html and returns the resulting nodes.
+// Like a browser, it never fails: end tags without a matching start tag
+// are ignored and elements which are not closed are closed at the end.
+// Some start tags also close open elements without an end tag, e.g.
+// <li>one<li>two results in two sibling li elements (see impliedEnds).
+func Parse(src string) []*Node {
+	p := &parser{src: src, root: &Node{Type: DocumentNode}}
+	p.stack = []*Node{p.root}
+	p.parse()
+	children := p.root.Children
+	p.root.setChildren(nil)
+	return children
+}
+
+// parser holds the state for Parse.
+type parser struct {
+	src   string
+	pos   int
+	root  *Node
+	stack []*Node
+}
+
+func (p *parser) parse() {
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end == -1 {
+				p.append(&Node{Type: CommentNode, Data: rest[4:]})
+				p.pos = len(p.src)
+			} else {
+				p.append(&Node{Type: CommentNode, Data: rest[4 : 4+end]})
+				p.pos += 4 + end + 3
+			}
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isLetter(rest[2]):
+			p.parseEndTag()
+		case rest[0] == '<' && len(rest) > 1 && isLetter(rest[1]):
+			p.parseStartTag()
+		default:
+			end := strings.IndexByte(rest[1:], '<')
+			if end == -1 {
+				end = len(rest)
+			} else {
+				end++
+			}
+			p.appendText(unescape(rest[:end]))
+			p.pos += end
+		}
+	}
+}
+
+// current returns the element that nodes are currently added to.
+func (p *parser) current() *Node {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *parser) append(n *Node) {
+	parent := p.current()
+	n.Parent = parent
+	parent.Children = append(parent.Children, n)
+}
+
+// appendText adds text to the current element, merging it with the
+// previous text node if there is one.
+func (p *parser) appendText(text string) {
+	parent := p.current()
+	if last := len(parent.Children) - 1; last >= 0 && parent.Children[last].Type == TextNode {
+		parent.Children[last].Data += text
+		return
+	}
+	p.append(&Node{Type: TextNode, Data: text})
+}
+
+func (p *parser) parseStartTag() {
+	p.pos++
+	el := &Node{Type: ElementNode, Tag: strings.ToLower(p.readName())}
+	selfClosing := false
+	for p.pos < len(p.src) {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			break
+		}
+		if p.src[p.pos] == '>' {
+			p.pos++
+			break
+		}
+		if strings.HasPrefix(p.src[p.pos:], "/>") {
+			selfClosing = true
+			p.pos += 2
+			break
+		}
+		if p.src[p.pos] == '/' {
+			p.pos++
+			continue
+		}
+		name := strings.ToLower(p.readName())
+		if name == "" {
+			// Skip a character which can't start an attribute name.
+			p.pos++
+			continue
+		}
+		value := ""
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '=' {
+			p.pos++
+			p.skipSpace()
+			value = unescape(p.readValue())
+		}
+		if !el.HasAttribute(name) {
+			el.Attrs = append(el.Attrs, Attr{Name: name, Value: value})
+		}
+	}
+	p.closeImplied(el.Tag)
+	p.append(el)
+	switch {
+	case voidTags[el.Tag] || selfClosing:
+	case rawTextTags[el.Tag]:
+		rest := p.src[p.pos:]
+		end := strings.Index(strings.ToLower(rest), "</"+el.Tag)
+		if end == -1 {
+			end = len(rest)
+		}
+		if end > 0 {
+			el.Children = []*Node{{Type: TextNode, Data: rest[:end], Parent: el}}
+		}
+		p.pos += end
+	default:
+		p.stack = append(p.stack, el)
+	}
+}
+
+// closeImplied closes any open elements which are closed by a start tag
+// with the given tag, along with everything inside of them.
+func (p *parser) closeImplied(tag string) {
+	rule, found := impliedEnds[tag]
+	if !found {
+		return
+	}
+	closed := -1
+	for i := len(p.stack) - 1; i > 0; i-- {
+		open := p.stack[i].Tag
+		if rule.closes[open] {
+			closed = i
+		} else if rule.scope[open] {
+			break
+		}
+	}
+	if closed != -1 {
+		p.stack = p.stack[:closed]
+	}
+}
+
+func (p *parser) parseEndTag() {
+	p.pos += 2
+	tag := strings.ToLower(p.readName())
+	if end := strings.IndexByte(p.src[p.pos:], '>'); end == -1 {
+		p.pos = len(p.src)
+	} else {
+		p.pos += end + 1
+	}
+	for i := len(p.stack) - 1; i > 0; i-- {
+		if p.stack[i].Tag == tag {
+			p.stack = p.stack[:i]
+			return
+		}
+	}
+}
+
+// readName reads a tag or attribute name.
+func (p *parser) readName() string {
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n\r\f/>=", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// readValue reads an attribute value, which may be quoted.
+func (p *parser) readValue() string {
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	if quote := p.src[p.pos]; quote == '"' || quote == '\'' {
+		p.pos++
+		end := strings.IndexByte(p.src[p.pos:], quote)
+		if end == -1 {
+			value := p.src[p.pos:]
+			p.pos = len(p.src)
+			return value
+		}
+		value := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		return value
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n\r\f>", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\n\r\f", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package fakedom
+
+import (
+	"testing"
+
+	"github.com/go-humble/temple/temple"
+)
+
+type todo struct {
+	ID    int
+	Title string
+	Done  bool
+}
+
+const todosTemplate = `<input id="new-todo" placeholder="{{ .Placeholder }}">
+<ul>{{ range .Todos }}<li data-key="{{ .ID }}"{{ if .Done }} class="done"{{ end }}>{{ .Title }}</li>{{ end }}</ul>
+{{ if .Footer }}<footer>{{ len .Todos }} items</footer>{{ else }}<!-- no footer -->{{ end }}`
+
+func TestPatchElement(t *testing.T) {
+	g := temple.NewGroup()
+	if err := g.AddTemplate("todos", todosTemplate); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := g.MustGetTemplate("todos")
+	doc := NewDocument(`<div id="app"></div>`)
+	app := doc.GetElementByID("app")
+	// patch patches app and then checks that the result is exactly the same
+	// as the html which is rendered by the template.
+	patch := func(data map[string]interface{}) {
+		if err := temple.PatchElement(tmpl, app, data); err != nil {
+			t.Fatalf("Unexpected error in PatchElement: %s", err.Error())
+		}
+		expected := NewDocument(`<div id="app"></div>`).GetElementByID("app")
+		if err := temple.ExecuteElement(tmpl, expected, data); err != nil {
+			t.Fatal(err)
+		}
+		if got := app.InnerHTML(); got != expected.InnerHTML() {
+			t.Fatalf("Result of PatchElement was not correct.\nExpected: %s\nBut got:  %s", expected.InnerHTML(), got)
+		}
+	}
+	patch(map[string]interface{}{
+		"Placeholder": "What needs to be done?",
+		"Todos":       []todo{{1, "one", false}, {2, "two", true}, {3, "three", false}},
+		"Footer":      true,
+	})
+	input := doc.GetElementByID("new-todo")
+	items := map[string]*Node{}
+	for _, li := range app.QuerySelectorAll("li") {
+		items[li.GetAttribute("data-key")] = li
+	}
+
+	// Reorder, remove, add, and change some of the todos, and change the
+	// attributes of the input.
+	patch(map[string]interface{}{
+		"Placeholder": "Anything else?",
+		"Todos":       []todo{{3, "three", true}, {1, "one!", false}, {4, "four", false}},
+		"Footer":      false,
+	})
+	if doc.GetElementByID("new-todo") != input {
+		t.Error("Expected the input to be patched instead of replaced")
+	}
+	if got := input.GetAttribute("placeholder"); got != "Anything else?" {
+		t.Errorf("Expected the placeholder of the input to be patched but got %q", got)
+	}
+	lis := app.QuerySelectorAll("li")
+	if len(lis) != 3 {
+		t.Fatalf("Expected 3 list items but got %d", len(lis))
+	}
+	if lis[0] != items["3"] || lis[1] != items["1"] {
+		t.Error("Expected list items with the same key to be moved instead of replaced")
+	}
+	if lis[2] == items["2"] {
+		t.Error("Expected the removed list item to not be reused for a new key")
+	}
+	if items["2"].Parent != nil {
+		t.Error("Expected the removed list item to be removed from the DOM")
+	}
+	if lis[0].GetAttribute("class") != "done" || lis[1].HasAttribute("class") {
+		t.Error("Expected the class attribute of the list items to be patched")
+	}
+
+	// Patching with the same data shouldn't change anything.
+	before := app.QuerySelectorAll("*")
+	patch(map[string]interface{}{
+		"Placeholder": "Anything else?",
+		"Todos":       []todo{{3, "three", true}, {1, "one!", false}, {4, "four", false}},
+	})
+	after := app.QuerySelectorAll("*")
+	if len(before) != len(after) {
+		t.Fatalf("Expected %d elements after patching with the same data but got %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("Expected element %d to be the same after patching with the same data", i)
+		}
+	}
+
+	// Remove everything and then add it back.
+	patch(map[string]interface{}{"Todos": []todo{}, "Footer": true})
+	patch(map[string]interface{}{
+		"Placeholder": "What needs to be done?",
+		"Todos":       []todo{{1, "one", false}, {2, "two", true}},
+		"Footer":      true,
+	})
+
+	// If the template can't be executed, the element should not change.
+	html := app.InnerHTML()
+	if err := temple.PatchElement(tmpl, app, map[string]interface{}{"Todos": 42}); err == nil {
+		t.Error("Expected an error in PatchElement when the template can't be executed")
+	}
+	if app.InnerHTML() != html {
+		t.Error("Expected PatchElement to not change the element when there is an error")
+	}
+}
+
+func TestPatchElementUnkeyed(t *testing.T) {
+	g := temple.NewGroup()
+	if err := g.AddTemplate("list", `{{ range . }}<p>{{ . }}</p>{{ end }}`); err != nil {
+		t.Fatal(err)
+	}
+	doc := NewDocument(`<div id="list"><h1>Old</h1><p>a</p>text<p>b</p></div>`)
+	list := doc.GetElementByID("list")
+	ps := list.QuerySelectorAll("p")
+	if err := temple.PatchElement(g.MustGetTemplate("list"), list, []string{"x", "y", "z"}); err != nil {
+		t.Fatalf("Unexpected error in PatchElement: %s", err.Error())
+	}
+	if expected, got := "<p>x</p><p>y</p><p>z</p>", list.InnerHTML(); got != expected {
+		t.Fatalf("Result of PatchElement was not correct.\nExpected: %s\nBut got:  %s", expected, got)
+	}
+	// Existing elements of the same kind should be reused in order.
+	newPs := list.QuerySelectorAll("p")
+	if newPs[0] != ps[0] || newPs[1] != ps[1] {
+		t.Error("Expected the existing paragraphs to be patched instead of replaced")
+	}
+}
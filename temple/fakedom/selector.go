@@ -0,0 +1,200 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package fakedom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selectorGroup is a comma separated list of selectors, which matches an
+// element if any of them do.
+type selectorGroup []selector
+
+// selector is a list of compound selectors separated by whitespace, i.e.
+// descendant combinators. The last compound selector must match the
+// element and the others must match its ancestors, in order.
+type selector []compoundSelector
+
+// compoundSelector matches elements with the given tag (unless it is
+// empty), id, classes, and attributes.
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+// attrSelector matches elements which have the attribute with the given
+// name, and if hasValue is true, the given value.
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// parseSelectorGroup parses src, which supports type, universal, id,
+// class, and attribute selectors, descendant combinators, and commas. It
+// panics if src is not supported, just like a browser throws an exception
+// for invalid selectors.
+func parseSelectorGroup(src string) selectorGroup {
+	group := selectorGroup{}
+	for _, part := range splitOutsideBrackets(src, func(c byte) bool { return c == ',' }) {
+		sel := selector{}
+		for _, compound := range splitOutsideBrackets(part, func(c byte) bool { return strings.IndexByte(" \t\n", c) != -1 }) {
+			if compound != "" {
+				sel = append(sel, parseCompoundSelector(src, compound))
+			}
+		}
+		if len(sel) == 0 {
+			panic(fmt.Sprintf("fakedom: invalid selector %q", src))
+		}
+		group = append(group, sel)
+	}
+	return group
+}
+
+// splitOutsideBrackets splits src at each character for which isSep
+// returns true, except inside of square brackets.
+func splitOutsideBrackets(src string, isSep func(byte) bool) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '[':
+			depth++
+		case src[i] == ']':
+			depth--
+		case depth == 0 && isSep(src[i]):
+			parts = append(parts, src[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, src[start:])
+}
+
+func parseCompoundSelector(full, src string) compoundSelector {
+	invalid := func() {
+		panic(fmt.Sprintf("fakedom: invalid or unsupported selector %q", full))
+	}
+	c := compoundSelector{}
+	readIdent := func(i int) (string, int) {
+		start := i
+		for i < len(src) && strings.IndexByte("#.[]*:>+~()", src[i]) == -1 {
+			i++
+		}
+		if i == start {
+			invalid()
+		}
+		return src[start:i], i
+	}
+	i := 0
+	if src[0] == '*' {
+		i++
+	} else if strings.IndexByte("#.[", src[0]) == -1 {
+		c.tag, i = readIdent(0)
+		c.tag = strings.ToLower(c.tag)
+	}
+	for i < len(src) {
+		switch src[i] {
+		case '#':
+			c.id, i = readIdent(i + 1)
+		case '.':
+			var class string
+			class, i = readIdent(i + 1)
+			c.classes = append(c.classes, class)
+		case '[':
+			end := strings.IndexByte(src[i:], ']')
+			if end == -1 {
+				invalid()
+			}
+			c.attrs = append(c.attrs, parseAttrSelector(src[i+1:i+end]))
+			i += end + 1
+		default:
+			invalid()
+		}
+	}
+	return c
+}
+
+func parseAttrSelector(src string) attrSelector {
+	eq := strings.IndexByte(src, '=')
+	if eq == -1 {
+		return attrSelector{name: strings.ToLower(strings.TrimSpace(src))}
+	}
+	value := strings.TrimSpace(src[eq+1:])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return attrSelector{
+		name:     strings.ToLower(strings.TrimSpace(src[:eq])),
+		value:    value,
+		hasValue: true,
+	}
+}
+
+// match returns true if el matches any of the selectors in g. Ancestors
+// are only considered up to but not including root.
+func (g selectorGroup) match(el, root *Node) bool {
+	for _, sel := range g {
+		if sel.match(el, root) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sel selector) match(el, root *Node) bool {
+	last := len(sel) - 1
+	if !sel[last].match(el) {
+		return false
+	}
+	ancestor := el.Parent
+	for i := last - 1; i >= 0; i-- {
+		for ancestor != nil && ancestor != root && !sel[i].match(ancestor) {
+			ancestor = ancestor.Parent
+		}
+		if ancestor == nil || ancestor == root {
+			return false
+		}
+		ancestor = ancestor.Parent
+	}
+	return true
+}
+
+func (c compoundSelector) match(el *Node) bool {
+	if el.Type != ElementNode {
+		return false
+	}
+	if c.tag != "" && c.tag != el.Tag {
+		return false
+	}
+	if c.id != "" && c.id != el.ID() {
+		return false
+	}
+	classes := strings.Fields(el.GetAttribute("class"))
+	for _, class := range c.classes {
+		if !containsString(classes, class) {
+			return false
+		}
+	}
+	for _, attr := range c.attrs {
+		value, found := el.attr(attr.name)
+		if !found || (attr.hasValue && value != attr.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fileSystem is a filesystem that template files can be collected from.
+// Filenames are in the form used by the filesystem, which for the OS
+// filesystem depends on the platform.
+type fileSystem interface {
+	// walk calls fn for each file or directory in the tree rooted at dir,
+	// including dir, in lexical order. If fn returns fs.SkipDir for a
+	// directory, its contents are skipped.
+	walk(dir string, fn func(filename string, isDir bool) error) error
+	// relName returns the path of filename relative to dir, using forward
+	// slashes as the separator on every platform.
+	relName(dir, filename string) (string, error)
+	// join joins dir and name, which does not contain any separators.
+	join(dir, name string) string
+	// readFile returns the contents of filename.
+	readFile(filename string) ([]byte, error)
+}
+
+// osFileSystem is the fileSystem for the OS filesystem.
+type osFileSystem struct{}
+
+func (osFileSystem) walk(dir string, fn func(filename string, isDir bool) error) error {
+	return filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			// A dir which does not exist has no files to collect, e.g. when
+			// there are no partials yet. Any other error, such as a
+			// subdirectory which can't be read, is returned so that files
+			// are not silently left out.
+			if filename == dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		return fn(filename, info.IsDir())
+	})
+}
+
+func (osFileSystem) relName(dir, filename string) (string, error) {
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (osFileSystem) join(dir, name string) string {
+	return filepath.Join(dir, name)
+}
+
+func (osFileSystem) readFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+// ioFileSystem is the fileSystem for an fs.FS. Filenames use separator,
+// which is always "/" except in tests, where other separators are used to
+// simulate other platforms.
+type ioFileSystem struct {
+	fsys      fs.FS
+	separator string
+}
+
+// toSlash returns filename with separator replaced by forward slashes,
+// which makes it a valid path for fsys.
+func (files ioFileSystem) toSlash(filename string) string {
+	return path.Clean(strings.Replace(filename, files.separator, "/", -1))
+}
+
+// fromSlash returns filename with forward slashes replaced by separator.
+func (files ioFileSystem) fromSlash(filename string) string {
+	return strings.Replace(filename, "/", files.separator, -1)
+}
+
+func (files ioFileSystem) walk(dir string, fn func(filename string, isDir bool) error) error {
+	return fs.WalkDir(files.fsys, files.toSlash(dir), func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return fn(files.fromSlash(filename), entry.IsDir())
+	})
+}
+
+func (files ioFileSystem) relName(dir, filename string) (string, error) {
+	dir, filename = files.toSlash(dir), files.toSlash(filename)
+	switch {
+	case dir == filename:
+		return ".", nil
+	case dir == ".":
+		return filename, nil
+	case strings.HasPrefix(filename, dir+"/"):
+		return strings.TrimPrefix(filename, dir+"/"), nil
+	}
+	return "", fmt.Errorf("temple: %s is not in %s", filename, dir)
+}
+
+func (files ioFileSystem) join(dir, name string) string {
+	return files.fromSlash(path.Join(files.toSlash(dir), name))
+}
+
+func (files ioFileSystem) readFile(filename string) ([]byte, error) {
+	return fs.ReadFile(files.fsys, files.toSlash(filename))
+}
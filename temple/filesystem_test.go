@@ -0,0 +1,158 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// collectNames returns the names and filenames collected by m from dir in
+// files, in the order that they were collected.
+func collectNames(t *testing.T, m *fileMatcher, files fileSystem, dir string) (names, filenames []string, err error) {
+	err = m.collectFrom(files, dir, func(name, filename string) error {
+		names = append(names, name)
+		filenames = append(filenames, filename)
+		return nil
+	})
+	return names, filenames, err
+}
+
+func TestCollectNames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/b.tmpl":           {},
+		"templates/a/z.tmpl":         {},
+		"templates/a.tmpl":           {},
+		"templates/a-b.tmpl":         {},
+		"templates/todos/index.tmpl": {},
+		"templates/todos/notes.txt":  {},
+		"other/c.tmpl":               {},
+	}
+	expectedNames := []string{"a", "a-b", "a/z", "b", "todos/index"}
+	// Simulate a platform which uses backslashes as separators. The names
+	// should use forward slashes no matter how dir is written.
+	files := ioFileSystem{fsys: fsys, separator: `\`}
+	for _, dir := range []string{`templates`, `templates\`, `.\templates`, `other\..\templates`} {
+		names, filenames, err := collectNames(t, newFileMatcher(nil, nil), files, dir)
+		if err != nil {
+			t.Errorf("Unexpected error in collectFrom for %s: %s", dir, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(names, expectedNames) {
+			t.Errorf("Names collected from %s were not correct.\nExpected: %v\nBut got:  %v", dir, expectedNames, names)
+		}
+		if expected := `templates\todos\index.tmpl`; filenames[len(filenames)-1] != expected {
+			t.Errorf("Expected filename for todos/index to be %s but got %s", expected, filenames[len(filenames)-1])
+		}
+	}
+	// When dir is the root, names include the whole path.
+	names, _, err := collectNames(t, newFileMatcher(nil, nil), files, ".")
+	if err != nil {
+		t.Fatalf("Unexpected error in collectFrom: %s", err.Error())
+	}
+	expectedNames = []string{"other/c", "templates/a", "templates/a-b", "templates/a/z", "templates/b", "templates/todos/index"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Names collected from the root were not correct.\nExpected: %v\nBut got:  %v", expectedNames, names)
+	}
+	// The ignore file and ignore patterns should also work with other
+	// separators.
+	fsys["templates/"+IgnoreFile] = &fstest.MapFile{Data: []byte("todos/\n")}
+	names, _, err = collectNames(t, newFileMatcher(nil, []string{"a/*"}), files, `templates\`)
+	if err != nil {
+		t.Fatalf("Unexpected error in collectFrom: %s", err.Error())
+	}
+	expectedNames = []string{"a", "a-b", "b"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Names collected with ignore patterns were not correct.\nExpected: %v\nBut got:  %v", expectedNames, names)
+	}
+}
+
+func TestCollectDuplicateNames(t *testing.T) {
+	files := ioFileSystem{
+		fsys: fstest.MapFS{
+			"templates/index.tmpl": {},
+			"templates/index.html": {},
+		},
+		separator: "/",
+	}
+	// With only the default extensions, there are no duplicates.
+	if _, _, err := collectNames(t, newFileMatcher(nil, nil), files, "templates"); err != nil {
+		t.Errorf("Unexpected error in collectFrom: %s", err.Error())
+	}
+	called := false
+	err := newFileMatcher([]string{".tmpl", ".html"}, nil).collectFrom(files, "templates", func(name, filename string) error {
+		called = true
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Expected an error in collectFrom for two files with the same name but got none")
+	}
+	if !strings.Contains(err.Error(), "would both be named index") {
+		t.Errorf("Error from collectFrom was not correct: %s", err.Error())
+	}
+	if called {
+		t.Error("Expected collectFrom to not call handler when there are duplicate names")
+	}
+}
+
+func TestCollectTemplateFilesOS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-collect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, "b.tmpl"), "")
+	writeTestFile(t, filepath.Join(dir, "a", "z.tmpl"), "")
+	writeTestFile(t, filepath.Join(dir, "a.tmpl"), "")
+	expectedNames := []string{"a", "a/z", "b"}
+	for _, d := range []string{dir, dir + string(os.PathSeparator), filepath.Join(dir, "a", "..")} {
+		names := []string{}
+		if err := collectTemplateFiles(d, func(name, filename string) error {
+			names = append(names, name)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, expectedNames) {
+			t.Errorf("Names collected from %s were not correct.\nExpected: %v\nBut got:  %v", d, expectedNames, names)
+		}
+	}
+}
+
+func TestCollectTemplateFilesOSErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-collect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, "a.tmpl"), "")
+	handler := func(name, filename string) error { return nil }
+	// A dir which does not exist has no files.
+	if err := collectTemplateFiles(filepath.Join(dir, "missing"), handler); err != nil {
+		t.Errorf("Unexpected error in collectTemplateFiles for a dir which does not exist: %s", err.Error())
+	}
+	// Any other error is returned.
+	if err := collectTemplateFiles(filepath.Join(dir, "a.tmpl", "b"), handler); err == nil {
+		t.Error("Expected an error in collectTemplateFiles for a dir inside of a file but got none")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("Skipping the test for an unreadable subdirectory because root can read it")
+	}
+	unreadable := filepath.Join(dir, "private")
+	writeTestFile(t, filepath.Join(unreadable, "b.tmpl"), "")
+	if err := os.Chmod(unreadable, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(unreadable, 0755)
+	if err := collectTemplateFiles(dir, handler); err == nil {
+		t.Error("Expected an error in collectTemplateFiles for an unreadable subdirectory but got none")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/fs"
+)
+
+// AddTemplatesFS recursively adds all the .tmpl files in dir and its
+// subdirectories in fsys to the group as regular templates. It works just
+// like AddTemplateFiles, except that the files are read from fsys, which
+// could be an embed.FS, a zip file, or anything else that implements
+// fs.FS. dir must be a valid path for fsys, i.e. it uses forward slashes
+// and is relative to the root of fsys. Use "." for the root itself.
+func (g *Group) AddTemplatesFS(fsys fs.FS, dir string) error {
+	return g.addFS(fsys, dir, templateKind)
+}
+
+// AddPartialsFS recursively adds all the .tmpl files in dir and its
+// subdirectories in fsys to the group as partials. It works just like
+// AddPartialFiles, except that the files are read from fsys. See
+// AddTemplatesFS.
+func (g *Group) AddPartialsFS(fsys fs.FS, dir string) error {
+	return g.addFS(fsys, dir, partialKind)
+}
+
+// AddLayoutsFS recursively adds all the .tmpl files in dir and its
+// subdirectories in fsys to the group as layouts. It works just like
+// AddLayoutFiles, except that the files are read from fsys. See
+// AddTemplatesFS.
+func (g *Group) AddLayoutsFS(fsys fs.FS, dir string) error {
+	return g.addFS(fsys, dir, layoutKind)
+}
+
+// AddAllFS adds the .tmpl files located in templatesDir, partialsDir, and
+// layoutsDir in fsys to the group as regular templates, partials, and
+// layouts, respectively. It works just like AddAllFiles, except that the
+// files are read from fsys. partialsDir and layoutsDir may be empty strings,
+// in which case no partials or layouts are added.
+func (g *Group) AddAllFS(fsys fs.FS, templatesDir, partialsDir, layoutsDir string) error {
+	if partialsDir != "" {
+		if err := g.AddPartialsFS(fsys, partialsDir); err != nil {
+			return err
+		}
+	}
+	if layoutsDir != "" {
+		if err := g.AddLayoutsFS(fsys, layoutsDir); err != nil {
+			return err
+		}
+	}
+	return g.AddTemplatesFS(fsys, templatesDir)
+}
+
+// addFS adds all the .tmpl files in dir and its subdirectories in fsys to
+// the group as the given kind of template.
+func (g *Group) addFS(fsys fs.FS, dir string, kind sourceKind) error {
+	return collectTemplateFilesFS(fsys, dir, func(name, filename string) error {
+		return g.add(&groupSource{kind: kind, name: name, filename: filename, fsys: fsys})
+	})
+}
+
+// collectTemplateFilesFS works just like collectTemplateFiles, except that
+// it navigates through dir in fsys instead of the OS filesystem.
+func collectTemplateFilesFS(fsys fs.FS, dir string, handler func(name, filename string) error) error {
+	return newFileMatcher(nil, nil).collectFrom(ioFileSystem{fsys: fsys, separator: "/"}, dir, handler)
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAddAllFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/templates/todos/index.tmpl": {Data: []byte(`{{ define "content" }}<ul>{{ range . }}{{ template "partials/todo" . }}{{ end }}</ul>{{ end }}{{ template "layouts/app" . }}`)},
+		"app/templates/readme.md":        {Data: []byte("Not a template")},
+		"app/partials/todo.tmpl":         {Data: []byte(`<li>{{ .Title }}</li>`)},
+		"app/layouts/app.tmpl":           {Data: []byte(`<body>{{ template "content" . }}</body>`)},
+	}
+	g := NewGroup()
+	if err := g.AddAllFS(fsys, "app/templates", "app/partials", "app/layouts/"); err != nil {
+		t.Fatalf("Unexpected error in AddAllFS: %s", err.Error())
+	}
+	type Todo struct {
+		Title string
+	}
+	todosTmpl, err := g.GetTemplate("todos/index")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, todosTmpl, []Todo{{"One"}, {"Two"}}, "<body><ul><li>One</li><li>Two</li></ul></body>")
+	if _, err := g.GetPartial("todo"); err != nil {
+		t.Errorf("Unexpected error in GetPartial: %s", err.Error())
+	}
+	if _, err := g.GetLayout("app"); err != nil {
+		t.Errorf("Unexpected error in GetLayout: %s", err.Error())
+	}
+	if _, err := g.GetTemplate("readme"); err == nil {
+		t.Error("Expected files without the .tmpl extension to be ignored")
+	}
+	if err := NewGroup().AddTemplatesFS(fsys, "missing"); err == nil {
+		t.Error("Expected an error in AddTemplatesFS for a directory which does not exist but got none")
+	}
+}
+
+func TestAddTemplatesFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"home.tmpl":         {Data: []byte("home")},
+		"people/index.tmpl": {Data: []byte("people")},
+	}
+	g := NewGroup()
+	if err := g.AddTemplatesFS(fsys, "."); err != nil {
+		t.Fatalf("Unexpected error in AddTemplatesFS: %s", err.Error())
+	}
+	expectExecutorOutputs(t, g.MustGetTemplate("home"), nil, "home")
+	expectExecutorOutputs(t, g.MustGetTemplate("people/index"), nil, "people")
+}
+
+func TestReloadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/home.tmpl": {Data: []byte("Hello"), ModTime: time.Unix(1, 0)},
+	}
+	g := NewGroup()
+	g.EnableReload()
+	if err := g.AddTemplatesFS(fsys, "templates"); err != nil {
+		t.Fatalf("Unexpected error in AddTemplatesFS: %s", err.Error())
+	}
+	expectExecutorOutputs(t, g.MustGetTemplate("home"), nil, "Hello")
+	fsys["templates/home.tmpl"] = &fstest.MapFile{Data: []byte("Goodbye"), ModTime: time.Unix(2, 0)}
+	expectExecutorOutputs(t, g.MustGetTemplate("home"), nil, "Goodbye")
+}
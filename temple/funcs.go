@@ -0,0 +1,128 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// funcsAlias is the name used to import the funcs package in
+// generated code. It is unlikely to collide with the name of the
+// package being generated.
+const funcsAlias = "templefuncs"
+
+// funcsVar represents an exported variable in some importable
+// go package which holds a map of function names to functions,
+// typically a template.FuncMap. It is given to Build as a string
+// of the form "import/path.VarName".
+type funcsVar struct {
+	ImportPath string
+	Name       string
+}
+
+// parseFuncsVar parses a string of the form "import/path.VarName"
+// and returns the corresponding funcsVar.
+func parseFuncsVar(s string) (*funcsVar, error) {
+	i := strings.LastIndex(s, ".")
+	if i <= strings.LastIndex(s, "/") || i == len(s)-1 {
+		return nil, fmt.Errorf("temple: funcs must be of the form import/path.VarName but got %q", s)
+	}
+	fv := &funcsVar{
+		ImportPath: s[:i],
+		Name:       s[i+1:],
+	}
+	if !ast.IsExported(fv.Name) {
+		return nil, fmt.Errorf("temple: funcs variable %s is not exported", fv.Name)
+	}
+	return fv, nil
+}
+
+// Alias returns the name that the funcs package is imported with
+// in generated code.
+func (fv funcsVar) Alias() string {
+	return funcsAlias
+}
+
+// String returns fv in the same form accepted by parseFuncsVar.
+func (fv funcsVar) String() string {
+	return fv.ImportPath + "." + fv.Name
+}
+
+// funcNames finds the package for fv and returns the keys of the map
+// literal it is declared with. The functions themselves can't be
+// loaded at build time, but text/template only needs to know their
+// names in order to parse a template.
+func (fv funcsVar) funcNames() ([]string, error) {
+	pkg, err := build.Import(fv.ImportPath, ".", 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, filename := range pkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, filename), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, ident := range valueSpec.Names {
+					if ident.Name != fv.Name {
+						continue
+					}
+					if i >= len(valueSpec.Values) {
+						return nil, fmt.Errorf("temple: funcs variable %s must be declared with a map literal", fv)
+					}
+					return mapLiteralKeys(fv, valueSpec.Values[i])
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("temple: could not find funcs variable %s", fv)
+}
+
+// mapLiteralKeys returns the string keys of expr, which must be
+// a composite literal with string literals for keys.
+func mapLiteralKeys(fv funcsVar, expr ast.Expr) ([]string, error) {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, fmt.Errorf("temple: funcs variable %s must be declared with a map literal", fv)
+	}
+	names := []string{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, fmt.Errorf("temple: funcs variable %s must be declared with a map literal", fv)
+		}
+		key, ok := kv.Key.(*ast.BasicLit)
+		if !ok || key.Kind != token.STRING {
+			return nil, fmt.Errorf("temple: the keys of funcs variable %s must be string literals", fv)
+		}
+		name, err := strconv.Unquote(key.Value)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// stubFunc is added to the FuncMap in place of each function in a
+// funcsVar while checking templates for compilation errors.
+func stubFunc(args ...interface{}) interface{} {
+	return nil
+}
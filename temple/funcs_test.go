@@ -19,5 +19,37 @@ func TestCustomFunc(t *testing.T) {
 	if err := g.AddTemplate("test", `{{ greet "world"}}`); err != nil {
 		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
 	}
-	expectExecutorOutputs(t, g.templates["test"], nil, "Hello, world!")
+	expectExecutorOutputs(t, g.MustGetTemplate("test"), nil, "Hello, world!")
+}
+
+func TestParseFuncsVar(t *testing.T) {
+	fv, err := parseFuncsVar("github.com/me/app/helpers.FuncMap")
+	if err != nil {
+		t.Fatalf("Unexpected error in parseFuncsVar: %s", err.Error())
+	}
+	if fv.ImportPath != "github.com/me/app/helpers" {
+		t.Errorf("Expected ImportPath to be %s but got %s", "github.com/me/app/helpers", fv.ImportPath)
+	}
+	if fv.Name != "FuncMap" {
+		t.Errorf("Expected Name to be %s but got %s", "FuncMap", fv.Name)
+	}
+	for _, invalid := range []string{"", "FuncMap", "github.com/me/app.helpers/funcs", "github.com/me/app/helpers.", "github.com/me/app/helpers.funcMap"} {
+		if _, err := parseFuncsVar(invalid); err == nil {
+			t.Errorf("Expected an error in parseFuncsVar for %q but got none", invalid)
+		}
+	}
+}
+
+func TestFuncNames(t *testing.T) {
+	fv, err := parseFuncsVar(funcsVarName)
+	if err != nil {
+		t.Fatalf("Unexpected error in parseFuncsVar: %s", err.Error())
+	}
+	names, err := fv.funcNames()
+	if err != nil {
+		t.Fatalf("Unexpected error in funcNames: %s", err.Error())
+	}
+	if len(names) != 1 || names[0] != "greet" {
+		t.Errorf("Expected funcNames to return [greet] but got %v", names)
+	}
 }
@@ -0,0 +1,94 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+)
+
+// InsertMode determines where InsertElement and InsertEl insert the
+// rendered nodes, relative to an element.
+type InsertMode int
+
+const (
+	// Append inserts the nodes after the last child of the element.
+	Append InsertMode = iota
+	// Prepend inserts the nodes before the first child of the element.
+	Prepend
+	// Replace replaces the element itself with the nodes, like setting
+	// its outerHTML.
+	Replace
+	// Before inserts the nodes right before the element.
+	Before
+	// After inserts the nodes right after the element.
+	After
+)
+
+func (mode InsertMode) String() string {
+	switch mode {
+	case Append:
+		return "Append"
+	case Prepend:
+		return "Prepend"
+	case Replace:
+		return "Replace"
+	case Before:
+		return "Before"
+	case After:
+		return "After"
+	}
+	return fmt.Sprintf("InsertMode(%d)", int(mode))
+}
+
+// InsertElement executes an Executor with the given data, parses the result
+// into nodes which are not in the DOM yet (i.e. a DocumentFragment), and
+// then inserts them relative to el, depending on mode. Unlike
+// ExecuteElement, the existing contents of el are left alone. It returns
+// the inserted nodes, in order, so that event listeners can be attached to
+// them. Replace, Before, and After return an error if el has no parent.
+// An unknown mode is an error, and e is not executed.
+func InsertElement(e Executor, el Node, mode InsertMode, data interface{}) ([]Node, error) {
+	switch mode {
+	case Append, Prepend, Replace, Before, After:
+	default:
+		return nil, fmt.Errorf("temple: unknown insert mode %s", mode)
+	}
+	html, err := executeToString(e, data)
+	if err != nil {
+		return nil, err
+	}
+	if mode == Append || mode == Prepend {
+		nodes := el.ParseHTML(html)
+		var ref Node
+		if children := el.ChildNodes(); mode == Prepend && len(children) > 0 {
+			ref = children[0]
+		}
+		insertNodes(el, nodes, ref)
+		return nodes, nil
+	}
+	parent := el.ParentNode()
+	if parent == nil {
+		return nil, fmt.Errorf("temple: cannot insert with mode %s because the element has no parent", mode)
+	}
+	nodes := parent.ParseHTML(html)
+	ref := el
+	if mode == After {
+		ref = el.NextSibling()
+	}
+	insertNodes(parent, nodes, ref)
+	if mode == Replace {
+		parent.RemoveChild(el)
+	}
+	return nodes, nil
+}
+
+// insertNodes inserts nodes as children of parent, in order, right before
+// ref, or after the last child if ref is nil.
+func insertNodes(parent Node, nodes []Node, ref Node) {
+	for _, node := range nodes {
+		parent.InsertBefore(node, ref)
+	}
+}
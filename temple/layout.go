@@ -0,0 +1,139 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template/parse"
+)
+
+// layoutDirective is the key for the directive which declares the layout
+// that a template or layout extends, e.g. {{/* temple:layout app */}}.
+const layoutDirective = "layout"
+
+// layeredKey identifies a regular template which has been compiled
+// together with a layout by ExecuteWithLayout.
+type layeredKey struct {
+	name   string
+	layout string
+}
+
+// ExecuteWithLayout renders the regular template identified by name inside
+// the layout identified by layout and writes the output to wr. The layout
+// is executed with data, and any templates defined by the regular template,
+// including with the `define` or `block` actions, replace the templates
+// with the same names in the layout. That means a layout can declare
+// several named sections with `block`, each with some default content, and
+// a template only needs to define the sections it wants to change. The
+// rest of the regular template, outside of any `define` actions, is
+// ignored. If the layout extends another layout, the regular template is
+// rendered inside both of them. layout may include LayoutPrefix, and
+// overrides any layout declared by the regular template itself.
+func (g *Group) ExecuteWithLayout(wr io.Writer, name, layout string, data interface{}) error {
+	if err := g.prepare(); err != nil {
+		return err
+	}
+	key := layeredKey{name: name, layout: strings.TrimPrefix(layout, LayoutPrefix)}
+	g.mu.RLock()
+	template, found := g.layered[key]
+	g.mu.RUnlock()
+	if !found {
+		var err error
+		if template, err = g.compileLayered(key); err != nil {
+			return err
+		}
+	}
+	return template.Execute(wr, data)
+}
+
+// compileLayered compiles the regular template identified by key.name
+// together with the layout identified by key.layout and caches the result
+// until the group is compiled again.
+func (g *Group) compileLayered(key layeredKey) (*Template, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if template, found := g.layered[key]; found {
+		return template, nil
+	}
+	trees, found := g.templateTrees[key.name]
+	if !found {
+		return nil, fmt.Errorf("Could not find template named %s", key.name)
+	}
+	trees, err := g.inheritTrees(key.name, trees, key.layout, nil)
+	if err != nil {
+		return nil, err
+	}
+	sharedPartials, sharedLayouts := g.sharedTrees()
+	html, text, err := g.compileTemplate(key.name, trees, sharedPartials, sharedLayouts)
+	if err != nil {
+		return nil, err
+	}
+	template := &Template{Template: html, Text: text}
+	g.layered[key] = template
+	return template, nil
+}
+
+// resolveLayout returns the trees for the template or layout identified by
+// name, which has the given trees, after applying the layout it declares
+// with a layout directive, if any. extending holds the names of any layouts
+// which are currently being resolved, and is used to detect cycles. The
+// caller must hold g.mu.
+func (g *Group) resolveLayout(name string, trees map[string]*parse.Tree, extending []string) (map[string]*parse.Tree, error) {
+	layout := findDirective(trees[name], layoutDirective)
+	if layout == "" {
+		return trees, nil
+	}
+	return g.inheritTrees(name, trees, strings.TrimPrefix(layout, LayoutPrefix), extending)
+}
+
+// inheritTrees returns the trees for the template or layout identified by
+// name, which has the given trees, rendered inside the layout identified by
+// layout. The result holds the templates defined by the layout (and any
+// layouts it extends), replaced by the templates defined in trees, and
+// executing name executes the outermost layout. extending holds the names
+// of any layouts which are currently being resolved, and is used to detect
+// cycles. The caller must hold g.mu.
+func (g *Group) inheritTrees(name string, trees map[string]*parse.Tree, layout string, extending []string) (map[string]*parse.Tree, error) {
+	for i, other := range extending {
+		if other == layout {
+			cycle := append(extending[i:], layout)
+			return nil, fmt.Errorf("temple: layouts cannot extend each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	layoutTrees, found := g.layoutTrees[layout]
+	if !found {
+		return nil, fmt.Errorf("Could not find layout named %s", layout)
+	}
+	layoutTrees, err := g.resolveLayout(layout, layoutTrees, append(extending, layout))
+	if err != nil {
+		return nil, err
+	}
+	inherited := map[string]*parse.Tree{}
+	mergeTrees(inherited, layoutTrees, layout)
+	mergeTrees(inherited, trees, name)
+	root := layoutTrees[layout].Copy()
+	root.Name = name
+	inherited[name] = root
+	return inherited, nil
+}
+
+// mergeTrees adds each tree in src except for the one named skip to dest,
+// replacing any tree in dest with the same name. Just like when a template
+// is redefined in text/template, a tree with an empty body does not replace
+// an existing one.
+func mergeTrees(dest, src map[string]*parse.Tree, skip string) {
+	for treeName, tree := range src {
+		if treeName == skip {
+			continue
+		}
+		if _, found := dest[treeName]; found && parse.IsEmptyTree(tree.Root) {
+			continue
+		}
+		dest[treeName] = tree
+	}
+}
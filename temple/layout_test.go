@@ -0,0 +1,162 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// newTestLayoutGroup returns a group with a base layout which declares
+// some blocks with default content and a child layout which extends it.
+func newTestLayoutGroup(t *testing.T) *Group {
+	g := NewGroup()
+	if err := g.AddPartial("foo", "foo"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	layouts := map[string]string{
+		"base": `<title>{{ block "title" . }}Default{{ end }}</title>` +
+			`<nav>{{ block "nav" . }}{{ end }}</nav>` +
+			`<main>{{ block "content" . }}{{ end }}</main>`,
+		"child": `{{/* temple:layout base */}}{{ define "nav" }}{{ template "partials/foo" }}{{ end }}`,
+	}
+	for name, src := range layouts {
+		if err := g.AddLayout(name, src); err != nil {
+			t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
+		}
+	}
+	return g
+}
+
+func TestExecuteWithLayout(t *testing.T) {
+	g := newTestLayoutGroup(t)
+	templates := map[string]string{
+		// page only defines one block, so the default title should be used
+		"page": `{{ define "content" }}{{ . }}{{ end }}This is ignored`,
+		// titled overrides the default title, and an empty define should not
+		// replace the default
+		"titled": `{{ define "title" }}Title{{ end }}{{ define "nav" }}{{ end }}`,
+	}
+	for name, src := range templates {
+		if err := g.AddTemplate(name, src); err != nil {
+			t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+		}
+	}
+	testCases := []struct {
+		name     string
+		layout   string
+		expected string
+	}{
+		{"page", "base", "<title>Default</title><nav></nav><main>&lt;b&gt;</main>"},
+		{"page", "layouts/base", "<title>Default</title><nav></nav><main>&lt;b&gt;</main>"},
+		{"titled", "base", "<title>Title</title><nav></nav><main></main>"},
+		{"page", "child", "<title>Default</title><nav>foo</nav><main>&lt;b&gt;</main>"},
+		{"titled", "child", "<title>Title</title><nav>foo</nav><main></main>"},
+	}
+	for _, tc := range testCases {
+		buf := bytes.NewBuffer([]byte{})
+		if err := g.ExecuteWithLayout(buf, tc.name, tc.layout, "<b>"); err != nil {
+			t.Errorf("Unexpected error in ExecuteWithLayout(%q, %q): %s", tc.name, tc.layout, err.Error())
+			continue
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("Output of ExecuteWithLayout(%q, %q) was not correct.\nExpected: %s\nBut got:  %s", tc.name, tc.layout, tc.expected, buf.String())
+		}
+	}
+	// The templates themselves should not be affected.
+	pageTmpl, err := g.GetTemplate("page")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, pageTmpl, nil, "This is ignored")
+	if err := g.ExecuteWithLayout(bytes.NewBuffer([]byte{}), "page", "missing", nil); err == nil {
+		t.Error("Expected an error in ExecuteWithLayout for a layout which does not exist but got none")
+	}
+	if err := g.ExecuteWithLayout(bytes.NewBuffer([]byte{}), "missing", "base", nil); err == nil {
+		t.Error("Expected an error in ExecuteWithLayout for a template which does not exist but got none")
+	}
+}
+
+func TestLayoutDirective(t *testing.T) {
+	g := newTestLayoutGroup(t)
+	templates := map[string]string{
+		"base":  `{{/* temple:layout base */}}{{ define "title" }}Base{{ end }}`,
+		"child": "{{- /* temple:layout layouts/child */ -}}\n{{ define \"content\" }}Child{{ end }}",
+		// none does not declare a layout, so it should be executed as is
+		"none": `{{/* temple:data string */}}None`,
+	}
+	for name, src := range templates {
+		if err := g.AddTemplate(name, src); err != nil {
+			t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+		}
+	}
+	expected := map[string]string{
+		"base":  "<title>Base</title><nav></nav><main></main>",
+		"child": "<title>Default</title><nav>foo</nav><main>Child</main>",
+		"none":  "None",
+	}
+	for name, output := range expected {
+		tmpl, err := g.GetTemplate(name)
+		if err != nil {
+			t.Errorf("Unexpected error in GetTemplate: %s", err.Error())
+			continue
+		}
+		expectExecutorOutputs(t, tmpl, nil, output)
+	}
+	// Executing the child layout directly should also render the base layout.
+	childLayout, err := g.GetLayout("child")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetLayout: %s", err.Error())
+	}
+	expectExecutorOutputs(t, childLayout, nil, "<title>Default</title><nav>foo</nav><main></main>")
+}
+
+func TestLayoutDirectiveTrees(t *testing.T) {
+	// Trees parsed by parseSource keep the directive, so it also works for
+	// precompiled templates.
+	g := newTestLayoutGroup(t)
+	trees, err := parseSource("test", `{{/* temple:layout base */}}{{ define "title" }}Trees{{ end }}`, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error in parseSource: %s", err.Error())
+	}
+	if err := g.AddTemplateTrees("test", trees); err != nil {
+		t.Fatalf("Unexpected error in AddTemplateTrees: %s", err.Error())
+	}
+	expectExecutorOutputs(t, g.MustGetTemplate("test"), nil, "<title>Trees</title><nav></nav><main></main>")
+}
+
+func TestLayoutErrors(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"missing": {
+			"a": `{{/* temple:layout missing */}}`,
+		},
+		"cycle": {
+			"a": `{{/* temple:layout b */}}`,
+			"b": `{{/* temple:layout c */}}`,
+			"c": `{{/* temple:layout a */}}`,
+		},
+		"self": {
+			"a": `{{/* temple:layout a */}}`,
+		},
+	}
+	for name, layouts := range testCases {
+		g := NewGroup()
+		for layoutName, src := range layouts {
+			if err := g.AddLayout(layoutName, src); err != nil {
+				t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
+			}
+		}
+		err := g.Compile()
+		if err == nil {
+			t.Errorf("Expected an error in Compile for %s layout but got none", name)
+			continue
+		}
+		if name != "missing" && !strings.Contains(err.Error(), "cycle") {
+			t.Errorf("Expected error for %s layout to mention a cycle but got: %s", name, err.Error())
+		}
+	}
+}
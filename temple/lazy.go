@@ -0,0 +1,186 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A LazyGroup wraps a Group and delays adding templates, partials, and
+// layouts to it until they are needed. Each regular template is added the
+// first time it is requested with GetTemplate or MustGetTemplate. Since every
+// template is associated with every partial and layout, all the partials
+// and layouts are added together the first time anything is requested.
+// LazyGroup is used by code generated with the lazy option. It is safe for
+// concurrent use.
+type LazyGroup struct {
+	group      *Group
+	mu         sync.Mutex
+	sharedOnce sync.Once
+	sharedErr  error
+	shared     []func() error
+	names      []string
+	templates  map[string]*lazyTemplate
+}
+
+// lazyTemplate is a regular template that has not necessarily been
+// added to the group yet.
+type lazyTemplate struct {
+	once sync.Once
+	add  func() error
+	tmpl *Template
+	err  error
+}
+
+// NewLazyGroup creates, initializes, and returns a new LazyGroup which
+// adds templates, partials, and layouts to g.
+func NewLazyGroup(g *Group) *LazyGroup {
+	return &LazyGroup{
+		group:     g,
+		templates: map[string]*lazyTemplate{},
+	}
+}
+
+// AddTemplate registers a regular template with the given name. add
+// will be called the first time the template is requested, and should
+// add the template to the underlying group, e.g. with Group.AddTemplate.
+func (lg *LazyGroup) AddTemplate(name string, add func() error) {
+	lg.names = append(lg.names, name)
+	lg.templates[name] = &lazyTemplate{add: add}
+}
+
+// AddPartial registers a partial. add will be called the first time
+// anything is requested from the group, and should add the partial to
+// the underlying group, e.g. with Group.AddPartial.
+func (lg *LazyGroup) AddPartial(add func() error) {
+	lg.shared = append(lg.shared, add)
+}
+
+// AddLayout registers a layout. add will be called the first time
+// anything is requested from the group, and should add the layout to
+// the underlying group, e.g. with Group.AddLayout. Layouts are always
+// added after partials.
+func (lg *LazyGroup) AddLayout(add func() error) {
+	lg.AddPartial(add)
+}
+
+// loadShared adds all the partials and layouts to the underlying
+// group if they have not been added already.
+func (lg *LazyGroup) loadShared() error {
+	lg.sharedOnce.Do(func() {
+		lg.mu.Lock()
+		defer lg.mu.Unlock()
+		for _, add := range lg.shared {
+			if err := add(); err != nil {
+				lg.sharedErr = err
+				return
+			}
+		}
+	})
+	return lg.sharedErr
+}
+
+// GetTemplate adds the template identified by name to the underlying
+// group if needed and then returns it. It returns an error if the
+// template could not be found or could not be added.
+func (lg *LazyGroup) GetTemplate(name string) (*Template, error) {
+	if err := lg.loadShared(); err != nil {
+		return nil, err
+	}
+	lazy, found := lg.templates[name]
+	if !found {
+		return nil, fmt.Errorf("Could not find template named %s", name)
+	}
+	lazy.once.Do(func() {
+		lg.mu.Lock()
+		defer lg.mu.Unlock()
+		if lazy.err = lazy.add(); lazy.err != nil {
+			return
+		}
+		lazy.tmpl, lazy.err = lg.group.GetTemplate(name)
+	})
+	return lazy.tmpl, lazy.err
+}
+
+// GetPartial adds all the partials and layouts to the underlying group
+// if needed and then returns the partial identified by name. It returns
+// an error if the partial could not be found or if any partial or layout
+// could not be added.
+func (lg *LazyGroup) GetPartial(name string) (*Partial, error) {
+	if err := lg.loadShared(); err != nil {
+		return nil, err
+	}
+	return lg.group.GetPartial(name)
+}
+
+// GetLayout adds all the partials and layouts to the underlying group
+// if needed and then returns the layout identified by name. It returns
+// an error if the layout could not be found or if any partial or layout
+// could not be added.
+func (lg *LazyGroup) GetLayout(name string) (*Layout, error) {
+	if err := lg.loadShared(); err != nil {
+		return nil, err
+	}
+	return lg.group.GetLayout(name)
+}
+
+// ExecuteWithLayout adds the template identified by name to the underlying
+// group if needed and then renders it inside the layout identified by
+// layout. See Group.ExecuteWithLayout.
+func (lg *LazyGroup) ExecuteWithLayout(wr io.Writer, name, layout string, data interface{}) error {
+	if _, err := lg.GetTemplate(name); err != nil {
+		return err
+	}
+	return lg.group.ExecuteWithLayout(wr, name, layout, data)
+}
+
+// MustGetTemplate works like GetTemplate, except that it panics
+// instead of returning an error.
+func (lg *LazyGroup) MustGetTemplate(name string) *Template {
+	template, err := lg.GetTemplate(name)
+	if err != nil {
+		panic(err)
+	}
+	return template
+}
+
+// MustGetPartial works like GetPartial, except that it panics
+// instead of returning an error.
+func (lg *LazyGroup) MustGetPartial(name string) *Partial {
+	partial, err := lg.GetPartial(name)
+	if err != nil {
+		panic(err)
+	}
+	return partial
+}
+
+// MustGetLayout works like GetLayout, except that it panics
+// instead of returning an error.
+func (lg *LazyGroup) MustGetLayout(name string) *Layout {
+	layout, err := lg.GetLayout(name)
+	if err != nil {
+		panic(err)
+	}
+	return layout
+}
+
+// Load adds every registered template, partial, and layout to the
+// underlying group, stopping at and returning the first error. It
+// can be used to validate all the templates at a time of the caller's
+// choosing instead of when they are first requested.
+func (lg *LazyGroup) Load() error {
+	if err := lg.loadShared(); err != nil {
+		return err
+	}
+	for _, name := range lg.names {
+		if _, err := lg.GetTemplate(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
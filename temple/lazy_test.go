@@ -0,0 +1,113 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// newTestLazyGroup returns a LazyGroup with a partial, a layout, and a
+// template called "test" which uses both of them. Any extra templates are
+// also registered, using their names as keys and their sources as values.
+func newTestLazyGroup(extra map[string]string) (*Group, *LazyGroup) {
+	g := NewGroup()
+	lg := NewLazyGroup(g)
+	lg.AddPartial(func() error {
+		return g.AddPartial("foo", "foo")
+	})
+	lg.AddLayout(func() error {
+		return g.AddLayout("header", `<h2>{{ template "content" }} {{ template "partials/foo" }}</h2>`)
+	})
+	lg.AddTemplate("test", func() error {
+		return g.AddTemplate("test", `{{ define "content" }}test{{ end }}{{ template "layouts/header" }}`)
+	})
+	for name, src := range extra {
+		name, src := name, src
+		lg.AddTemplate(name, func() error {
+			return g.AddTemplate(name, src)
+		})
+	}
+	return g, lg
+}
+
+func TestLazyGroup(t *testing.T) {
+	g, lg := newTestLazyGroup(map[string]string{
+		"other": "other",
+	})
+	if len(g.templateTrees) != 0 || len(g.partialTrees) != 0 || len(g.layoutTrees) != 0 {
+		t.Fatal("Expected nothing to be added to the group before it was requested")
+	}
+	testTmpl, err := lg.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, testTmpl, nil, "<h2>test foo</h2>")
+	if _, found := g.templateTrees["other"]; found {
+		t.Error(`Expected template named "other" to not be added to the group before it was requested`)
+	}
+	if _, found := g.partialTrees["foo"]; !found {
+		t.Error(`Expected partial named "foo" to be added to the group`)
+	}
+	if _, err := lg.GetTemplate("missing"); err == nil {
+		t.Error("Expected an error in GetTemplate for a template which does not exist but got none")
+	}
+	if err := lg.Load(); err != nil {
+		t.Fatalf("Unexpected error in Load: %s", err.Error())
+	}
+	if _, found := g.templateTrees["other"]; !found {
+		t.Error(`Expected template named "other" to be added to the group by Load`)
+	}
+}
+
+func TestLazyGroupError(t *testing.T) {
+	_, lg := newTestLazyGroup(map[string]string{
+		"broken": "{{ .Title ",
+	})
+	// Templates which do compile should not be affected by the broken one.
+	if _, err := lg.GetTemplate("test"); err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := lg.GetTemplate("broken"); err == nil {
+			t.Error("Expected an error in GetTemplate for a template which does not compile but got none")
+		}
+	}
+	if err := lg.Load(); err == nil {
+		t.Error("Expected an error in Load when a template does not compile but got none")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustGetTemplate to panic for a template which does not compile")
+		}
+	}()
+	lg.MustGetTemplate("broken")
+}
+
+func TestLazyGroupConcurrent(t *testing.T) {
+	extra := map[string]string{}
+	for i := 0; i < 10; i++ {
+		extra[fmt.Sprintf("tmpl%d", i)] = fmt.Sprintf(`{{ template "partials/foo" }}%d`, i)
+	}
+	_, lg := newTestLazyGroup(extra)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tmpl, err := lg.GetTemplate(fmt.Sprintf("tmpl%d", i))
+				if err != nil {
+					t.Errorf("Unexpected error in GetTemplate: %s", err.Error())
+					return
+				}
+				expectExecutorOutputs(t, tmpl, nil, fmt.Sprintf("foo%d", i))
+			}(i)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultExtensions holds the file extensions of the templates, partials,
+// and layouts that are loaded from directories, unless other extensions
+// are given to Build with WithExtensions.
+var DefaultExtensions = []string{".tmpl"}
+
+// IgnoreFile is the name of the optional file in a templates, partials, or
+// layouts directory which holds patterns for files and directories in it
+// that should be ignored, one per line, in the same form as the patterns
+// for WithIgnore. Blank lines and lines starting with # are skipped.
+const IgnoreFile = ".templeignore"
+
+// fileMatcher decides which files in a directory are templates, partials,
+// or layouts, and what their names are.
+type fileMatcher struct {
+	// extensions holds the extensions of the files which are collected, in
+	// order of preference, each starting with a dot.
+	extensions []string
+	// ignore holds the patterns for files and directories which are not
+	// collected. See WithIgnore.
+	ignore []string
+}
+
+// newFileMatcher creates and returns a fileMatcher which collects files
+// with the given extensions, or with DefaultExtensions if there are none,
+// and ignores any files which match the given patterns. Whitespace is
+// trimmed from the extensions and patterns, and any which are empty are
+// skipped. A dot is added to the start of any extensions which don't
+// already have one.
+func newFileMatcher(extensions, ignore []string) *fileMatcher {
+	m := &fileMatcher{}
+	for _, ext := range extensions {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		m.extensions = append(m.extensions, ext)
+	}
+	if len(m.extensions) == 0 {
+		m.extensions = DefaultExtensions
+	}
+	for _, pattern := range ignore {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			m.ignore = append(m.ignore, pattern)
+		}
+	}
+	return m
+}
+
+// collect navigates recursively through dir and its subdirectories in the
+// OS filesystem and calls handler with the name and filename of each file
+// which has one of the extensions of m. See collectFrom.
+func (m *fileMatcher) collect(dir string, handler func(name, filename string) error) error {
+	return m.collectFrom(osFileSystem{}, dir, handler)
+}
+
+// collectFrom navigates recursively through dir and its subdirectories in
+// files and calls handler with the name and filename of each file which has
+// one of the extensions of m, in order of their names. The name is the path
+// of the file relative to dir, with forward slashes on every platform and
+// without the extension that matched (and without PartialPrefix or
+// LayoutPrefix). Any files or directories which match one of the ignore
+// patterns of m, or one of the patterns in the IgnoreFile in dir, are
+// skipped. If two files would have the same name, e.g. because they only
+// differ in their extensions, an error is returned and handler is not
+// called at all.
+func (m *fileMatcher) collectFrom(files fileSystem, dir string, handler func(name, filename string) error) error {
+	ignore, err := readIgnoreFile(files, files.join(dir, IgnoreFile))
+	if err != nil {
+		return err
+	}
+	ignore = append(ignore, m.ignore...)
+	filenames := map[string]string{}
+	names := []string{}
+	if err := files.walk(dir, func(filename string, isDir bool) error {
+		rel, err := files.relName(dir, filename)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if matchIgnore(ignore, rel, isDir) {
+			if isDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if isDir {
+			return nil
+		}
+		name, ok := m.trimExtension(rel)
+		if !ok {
+			return nil
+		}
+		if other, found := filenames[name]; found {
+			return fmt.Errorf("temple: %s and %s would both be named %s. Rename or ignore one of them.", other, filename, name)
+		}
+		filenames[name] = filename
+		names = append(names, name)
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := handler(name, filenames[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimExtension returns filename without the first of the extensions of m
+// that it ends with, and true if there is one.
+func (m *fileMatcher) trimExtension(filename string) (string, bool) {
+	for _, ext := range m.extensions {
+		if strings.HasSuffix(filename, ext) && len(filename) > len(ext) {
+			return strings.TrimSuffix(filename, ext), true
+		}
+	}
+	return "", false
+}
+
+// readIgnoreFile returns the patterns in the ignore file located at
+// filename in files, or nil if there is no such file.
+func readIgnoreFile(files fileSystem, filename string) ([]string, error) {
+	src, err := files.readFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	patterns := []string{}
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := checkIgnorePattern(line); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// checkIgnorePattern returns an error if pattern is not a valid pattern
+// for path.Match.
+func checkIgnorePattern(pattern string) error {
+	if _, err := path.Match(strings.Trim(pattern, "/"), ""); err != nil {
+		return fmt.Errorf("temple: invalid ignore pattern %q: %s", pattern, err.Error())
+	}
+	return nil
+}
+
+// matchIgnore returns true if the file or directory with the given path,
+// which is relative to the directory being collected and uses forward
+// slashes, matches any of the ignore patterns. A pattern which ends with a
+// slash only matches directories. Otherwise, a pattern which contains a
+// slash is matched against the whole path, and any other pattern is
+// matched against the last element of the path, so that "*.swp" matches
+// swap files in any directory.
+func matchIgnore(patterns []string, rel string, isDir bool) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			target = rel
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
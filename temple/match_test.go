@@ -0,0 +1,108 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFileMatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-match")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, filename := range []string{
+		"index.tmpl",
+		"show.html",
+		"edit.gohtml",
+		"notes.txt",
+		".index.tmpl.swp",
+		"node_modules/lib/widget.tmpl",
+		"drafts/new.tmpl",
+		"people/drafts.html",
+		"people/list.tmpl",
+	} {
+		writeTestFile(t, filepath.Join(dir, filename), "")
+	}
+	writeTestFile(t, filepath.Join(dir, IgnoreFile), "# Directories named drafts\ndrafts/\n\n")
+	m := newFileMatcher([]string{".tmpl", "html"}, []string{"*.swp", "node_modules"})
+	names := []string{}
+	if err := m.collect(dir, func(name, filename string) error {
+		names = append(names, name)
+		return nil
+	}); err != nil {
+		t.Fatalf("Unexpected error in collect: %s", err.Error())
+	}
+	sort.Strings(names)
+	expected := []string{
+		"index",
+		filepath.Join("people", "drafts"),
+		filepath.Join("people", "list"),
+		"show",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Names collected by fileMatcher were not correct.\nExpected: %v\nBut got:  %v", expected, names)
+	}
+	// An invalid pattern in the ignore file is an error.
+	writeTestFile(t, filepath.Join(dir, IgnoreFile), "[")
+	if err := m.collect(dir, func(name, filename string) error { return nil }); err == nil {
+		t.Error("Expected an error in collect for an ignore file with an invalid pattern but got none")
+	}
+	if _, err := newBuildOptions([]BuildOption{WithIgnore("[")}); err == nil {
+		t.Error("Expected an error in WithIgnore for an invalid pattern but got none")
+	}
+}
+
+func TestNewFileMatcherSkipsEmpty(t *testing.T) {
+	m := newFileMatcher([]string{" html", "", " .tmpl "}, []string{" *.swp", " "})
+	if expected := []string{".html", ".tmpl"}; !reflect.DeepEqual(m.extensions, expected) {
+		t.Errorf("Extensions were not correct.\nExpected: %v\nBut got:  %v", expected, m.extensions)
+	}
+	if expected := []string{"*.swp"}; !reflect.DeepEqual(m.ignore, expected) {
+		t.Errorf("Ignore patterns were not correct.\nExpected: %v\nBut got:  %v", expected, m.ignore)
+	}
+	// Only empty extensions are the same as no extensions.
+	if m := newFileMatcher([]string{"", " "}, nil); !reflect.DeepEqual(m.extensions, DefaultExtensions) {
+		t.Errorf("Expected DefaultExtensions for only empty extensions but got %v", m.extensions)
+	}
+}
+
+func TestBuildWithExtensions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-match")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, "templates", "index.html"), `<p>{{ template "partials/greeting" . }}</p>`)
+	writeTestFile(t, filepath.Join(dir, "partials", "greeting.gohtml"), `Hello, {{ . }}!`)
+	writeTestFile(t, filepath.Join(dir, "partials", "greeting.gohtml.swp"), `{{ template "partials/missing" }}`)
+	result, err := BuildWithConfig(BuildConfig{
+		Src:        filepath.Join(dir, "templates"),
+		Dest:       filepath.Join(dir, "templates.go"),
+		Partials:   filepath.Join(dir, "partials"),
+		Package:    "main",
+		Extensions: []string{".html", ".gohtml"},
+		Ignore:     []string{"*.swp"},
+		DryRun:     true,
+		Log:        ioutil.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error in BuildWithConfig: %s", err.Error())
+	}
+	expectedFiles := []string{
+		filepath.Join(dir, "partials", "greeting.gohtml"),
+		filepath.Join(dir, "templates", "index.html"),
+	}
+	if !reflect.DeepEqual(result.Files, expectedFiles) {
+		t.Errorf("Files from BuildWithConfig were not correct.\nExpected: %v\nBut got:  %v", expectedFiles, result.Files)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	texttemplate "text/template"
+)
+
+// Mode determines which builtin package a Group uses to compile its
+// templates, partials, and layouts.
+type Mode int
+
+const (
+	// HTMLMode compiles templates with the html/template package, which
+	// escapes data according to the context it appears in. It is the
+	// default.
+	HTMLMode Mode = iota
+	// TextMode compiles templates with the text/template package, which
+	// does not escape anything. It is meant for output that is not html,
+	// such as plain text emails, csv files, or configuration files.
+	TextMode
+)
+
+// String returns the name of the mode, i.e. "html" or "text".
+func (m Mode) String() string {
+	switch m {
+	case HTMLMode:
+		return "html"
+	case TextMode:
+		return "text"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// ParseMode returns the mode with the given name, which must be either
+// "html" or "text".
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "html":
+		return HTMLMode, nil
+	case "text":
+		return TextMode, nil
+	default:
+		return HTMLMode, fmt.Errorf("temple: unknown mode %q. Mode must be html or text.", name)
+	}
+}
+
+// NewTextGroup creates, initializes, and returns a new Group which uses
+// TextMode. Partials and layouts are associated with templates exactly the
+// same way as they are in a group created with NewGroup, but the Text field
+// is set on each Template, Partial, and Layout instead of the embedded
+// html/template Template.
+func NewTextGroup() *Group {
+	g := NewGroup()
+	g.mode = TextMode
+	return g
+}
+
+// Mode returns the mode the group uses to compile templates.
+func (g *Group) Mode() Mode {
+	return g.mode
+}
+
+// modeTemplate holds the methods which html/template and text/template
+// templates have in common and which work the same way in both modes.
+type modeTemplate interface {
+	Execute(wr io.Writer, data interface{}) error
+	ExecuteTemplate(wr io.Writer, name string, data interface{}) error
+	Name() string
+	DefinedTemplates() string
+}
+
+// byMode returns text if it is not nil, i.e. if the template was compiled
+// in TextMode, and html otherwise.
+func byMode(html *template.Template, text *texttemplate.Template) modeTemplate {
+	if text != nil {
+		return text
+	}
+	return html
+}
+
+// Execute applies the template to data and writes the output to wr,
+// using whichever package the template was compiled with.
+func (t Template) Execute(wr io.Writer, data interface{}) error {
+	return byMode(t.Template, t.Text).Execute(wr, data)
+}
+
+// ExecuteTemplate applies the template associated with t that has the
+// given name to data and writes the output to wr.
+func (t Template) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	return byMode(t.Template, t.Text).ExecuteTemplate(wr, name, data)
+}
+
+// Name returns the name of the template.
+func (t Template) Name() string {
+	return byMode(t.Template, t.Text).Name()
+}
+
+// DefinedTemplates returns a string listing the templates associated
+// with t, for use in error messages.
+func (t Template) DefinedTemplates() string {
+	return byMode(t.Template, t.Text).DefinedTemplates()
+}
+
+// Execute applies the partial to data and writes the output to wr,
+// using whichever package the partial was compiled with.
+func (p Partial) Execute(wr io.Writer, data interface{}) error {
+	return byMode(p.Template, p.Text).Execute(wr, data)
+}
+
+// ExecuteTemplate applies the template associated with p that has the
+// given name to data and writes the output to wr.
+func (p Partial) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	return byMode(p.Template, p.Text).ExecuteTemplate(wr, name, data)
+}
+
+// Name returns the name of the partial, without PartialPrefix.
+func (p Partial) Name() string {
+	return byMode(p.Template, p.Text).Name()
+}
+
+// DefinedTemplates returns a string listing the templates associated
+// with p, for use in error messages.
+func (p Partial) DefinedTemplates() string {
+	return byMode(p.Template, p.Text).DefinedTemplates()
+}
+
+// Execute applies the layout to data and writes the output to wr,
+// using whichever package the layout was compiled with.
+func (l Layout) Execute(wr io.Writer, data interface{}) error {
+	return byMode(l.Template, l.Text).Execute(wr, data)
+}
+
+// ExecuteTemplate applies the template associated with l that has the
+// given name to data and writes the output to wr.
+func (l Layout) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	return byMode(l.Template, l.Text).ExecuteTemplate(wr, name, data)
+}
+
+// Name returns the name of the layout, without LayoutPrefix.
+func (l Layout) Name() string {
+	return byMode(l.Template, l.Text).Name()
+}
+
+// DefinedTemplates returns a string listing the templates associated
+// with l, for use in error messages.
+func (l Layout) DefinedTemplates() string {
+	return byMode(l.Template, l.Text).DefinedTemplates()
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestTextMode(t *testing.T) {
+	g := NewTextGroup()
+	if err := g.AddPartial("signature", `-- {{ . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	if err := g.AddLayout("email", `{{ template "content" . }}{{ "\n" }}{{ template "partials/signature" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
+	}
+	if err := g.AddTemplate("test", `{{ define "content" }}Hello, {{ . }}!{{ end }}{{ template "layouts/email" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	if testTmpl.Text == nil || testTmpl.Template != nil {
+		t.Fatal("Expected a template in a text group to be compiled with text/template")
+	}
+	if testTmpl.Name() != "test" {
+		t.Errorf(`Expected template name to be "test" but got %q`, testTmpl.Name())
+	}
+	// Nothing should be escaped in text mode.
+	expectExecutorOutputs(t, testTmpl, "<Bob & Alice>", "Hello, <Bob & Alice>!\n-- <Bob & Alice>")
+	signature, err := g.GetPartial("signature")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetPartial: %s", err.Error())
+	}
+	if signature.PrefixedName() != "partials/signature" {
+		t.Errorf(`Expected prefixed name to be "partials/signature" but got %q`, signature.PrefixedName())
+	}
+	expectExecutorOutputs(t, signature, "me", "-- me")
+}
+
+func TestTextModeMethods(t *testing.T) {
+	// The methods which work in both modes should not panic in text mode.
+	g := NewTextGroup()
+	if err := g.AddPartial("signature", `-- {{ . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	if err := g.AddLayout("email", `{{ template "content" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
+	}
+	if err := g.AddTemplate("test", `{{ define "content" }}Hello, {{ . }}!{{ end }}{{ template "layouts/email" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	executors := map[string]modeTemplate{
+		"test":      g.MustGetTemplate("test"),
+		"signature": g.MustGetPartial("signature"),
+		"email":     g.MustGetLayout("email"),
+	}
+	for name, e := range executors {
+		if got := e.Name(); got != name {
+			t.Errorf("Expected Name to return %s but got %s", name, got)
+		}
+		if !strings.Contains(e.DefinedTemplates(), `"partials/signature"`) {
+			t.Errorf("Expected DefinedTemplates for %s to include partials/signature but got %s", name, e.DefinedTemplates())
+		}
+		buf := &bytes.Buffer{}
+		if err := e.ExecuteTemplate(buf, "partials/signature", "<Bob>"); err != nil {
+			t.Errorf("Unexpected error in ExecuteTemplate for %s: %s", name, err.Error())
+		} else if buf.String() != "-- <Bob>" {
+			t.Errorf("Expected ExecuteTemplate for %s to output %q but got %q", name, "-- <Bob>", buf.String())
+		}
+	}
+	// Everything else is available through Text.
+	testTmpl := g.MustGetTemplate("test")
+	if testTmpl.Template != nil {
+		t.Error("Expected the embedded html/template Template to be nil in text mode")
+	}
+	content := testTmpl.Text.Lookup("content")
+	if content == nil || content.Tree == nil {
+		t.Fatal("Expected Text.Lookup to return the content template in text mode")
+	}
+	expectExecutorOutputs(t, content, "<Bob>", "Hello, <Bob>!")
+}
+
+func TestHTMLModeMethods(t *testing.T) {
+	g := NewGroup()
+	if err := g.AddTemplate("test", `{{ define "content" }}Hello, {{ . }}!{{ end }}{{ template "content" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	testTmpl := g.MustGetTemplate("test")
+	// The methods of the embedded Template still return html/template types.
+	var content *template.Template = testTmpl.Lookup("content")
+	if content == nil {
+		t.Fatal("Expected Lookup to return the content template in html mode")
+	}
+	expectExecutorOutputs(t, content, "<Bob>", "Hello, &lt;Bob&gt;!")
+	if got := len(testTmpl.Templates()); got != 2 {
+		t.Errorf("Expected Templates to return 2 templates but got %d", got)
+	}
+	if !strings.Contains(testTmpl.DefinedTemplates(), `"content"`) {
+		t.Errorf("Expected DefinedTemplates to include content but got %s", testTmpl.DefinedTemplates())
+	}
+}
+
+func TestHTMLModeEscapes(t *testing.T) {
+	g := NewGroup()
+	if err := g.AddTemplate("test", `Hello, {{ . }}!`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	if testTmpl.Template == nil || testTmpl.Text != nil {
+		t.Fatal("Expected a template in an html group to be compiled with html/template")
+	}
+	expectExecutorOutputs(t, testTmpl, "<Bob & Alice>", "Hello, &lt;Bob &amp; Alice&gt;!")
+}
+
+func TestParseMode(t *testing.T) {
+	for name, expected := range map[string]Mode{"html": HTMLMode, "text": TextMode} {
+		mode, err := ParseMode(name)
+		if err != nil {
+			t.Errorf("Unexpected error in ParseMode: %s", err.Error())
+		}
+		if mode != expected || mode.String() != name {
+			t.Errorf("Expected ParseMode(%q) to return %s but got %s", name, expected, mode)
+		}
+	}
+	if _, err := ParseMode("markdown"); err == nil {
+		t.Error("Expected an error in ParseMode for an unknown mode but got none")
+	}
+}
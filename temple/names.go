@@ -0,0 +1,95 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// exportedName returns an exported go identifier for the template, partial,
+// or layout with the given name, starting with prefix. Each run of letters
+// and digits in name is capitalized and joined together, so
+// exportedName("Render", "todos/index") returns "RenderTodosIndex".
+func exportedName(prefix, name string) string {
+	buf := bytes.NewBufferString(prefix)
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// addNameConsts sets NameConst and PrefixedName for each of the collected
+// source files. The generated code declares a constant named NameConst
+// for each of them, which holds the name of the template, partial, or
+// layout. The names of partials and layouts include PartialPrefix and
+// LayoutPrefix, so that the constants can be used both with the `template`
+// action and with GetPartial and GetLayout. If several files would have the
+// same constant, e.g. todo-item and todo_item, the one whose prefixed name
+// comes first in sorted order keeps it, and the others get a numeric suffix
+// starting at 2, skipping any constants which are already taken. That way
+// every constant is unique and does not depend on the order of the files.
+func (data *templateData) addNameConsts() {
+	kinds := []struct {
+		files  []sourceFile
+		ident  string
+		prefix string
+	}{
+		{files: data.Templates, ident: "Template"},
+		{files: data.Partials, ident: "Partial", prefix: PartialPrefix},
+		{files: data.Layouts, ident: "Layout", prefix: LayoutPrefix},
+	}
+	// groups holds the files which would have each constant, by constant.
+	groups := map[string][]*sourceFile{}
+	for _, kind := range kinds {
+		for i, file := range kind.files {
+			prefixed := file.Name
+			if kind.prefix != "" {
+				prefixed = prefixedName(kind.prefix, file.Name)
+			}
+			nameConst := exportedName(kind.ident, file.Name)
+			kind.files[i].NameConst = nameConst
+			kind.files[i].PrefixedName = prefixed
+			groups[nameConst] = append(groups[nameConst], &kind.files[i])
+		}
+	}
+	collisions := []string{}
+	for nameConst, files := range groups {
+		if len(files) > 1 {
+			collisions = append(collisions, nameConst)
+		}
+	}
+	sort.Strings(collisions)
+	for _, nameConst := range collisions {
+		files := groups[nameConst]
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].PrefixedName < files[j].PrefixedName
+		})
+		n := 2
+		for _, file := range files[1:] {
+			for {
+				suffixed := nameConst + strconv.Itoa(n)
+				n++
+				if _, taken := groups[suffixed]; !taken {
+					file.NameConst = suffixed
+					groups[suffixed] = []*sourceFile{file}
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"testing"
+)
+
+func TestAddNameConsts(t *testing.T) {
+	data := &templateData{
+		Templates: []sourceFile{{Name: "todos/index"}, {Name: "people/show-all"}},
+		Partials:  []sourceFile{{Name: "todo"}},
+		Layouts:   []sourceFile{{Name: "app"}},
+	}
+	data.addNameConsts()
+	expected := []sourceFile{
+		{NameConst: "TemplateTodosIndex", PrefixedName: "todos/index"},
+		{NameConst: "TemplatePeopleShowAll", PrefixedName: "people/show-all"},
+		{NameConst: "PartialTodo", PrefixedName: "partials/todo"},
+		{NameConst: "LayoutApp", PrefixedName: "layouts/app"},
+	}
+	files := append(append(append([]sourceFile{}, data.Templates...), data.Partials...), data.Layouts...)
+	for i, file := range files {
+		if file.NameConst != expected[i].NameConst || file.PrefixedName != expected[i].PrefixedName {
+			t.Errorf("Expected constant %s = %q for %s but got %s = %q", expected[i].NameConst, expected[i].PrefixedName, file.Name, file.NameConst, file.PrefixedName)
+		}
+	}
+	// Files which would have the same constant get a numeric suffix in sorted
+	// order, skipping constants which are already taken.
+	for _, partials := range [][]sourceFile{
+		{{Name: "todo_item"}, {Name: "todo-item2"}, {Name: "todo-item"}, {Name: "todo.item"}},
+		{{Name: "todo.item"}, {Name: "todo-item"}, {Name: "todo-item2"}, {Name: "todo_item"}},
+	} {
+		data = &templateData{Partials: partials}
+		data.addNameConsts()
+		expected := map[string]string{
+			"todo-item":  "PartialTodoItem",
+			"todo-item2": "PartialTodoItem2",
+			"todo.item":  "PartialTodoItem3",
+			"todo_item":  "PartialTodoItem4",
+		}
+		for _, file := range data.Partials {
+			if file.NameConst != expected[file.Name] {
+				t.Errorf("Expected constant %s for %s but got %s", expected[file.Name], file.Name, file.NameConst)
+			}
+		}
+	}
+}
+
+func TestRenderFuncsUseNameConsts(t *testing.T) {
+	// Templates which would have the same render function get the same
+	// numeric suffix as their name constants.
+	data := &templateData{
+		Templates: []sourceFile{{Name: "todo_item"}, {Name: "todo-item"}},
+	}
+	data.addNameConsts()
+	if err := data.addRenderFuncs(nil); err != nil {
+		t.Fatalf("Unexpected error in addRenderFuncs: %s", err.Error())
+	}
+	expected := map[string]string{
+		"todo-item": "RenderTodoItem",
+		"todo_item": "RenderTodoItem2",
+	}
+	for _, file := range data.Templates {
+		if file.RenderFunc != expected[file.Name] {
+			t.Errorf("Expected render function %s for %s but got %s", expected[file.Name], file.Name, file.RenderFunc)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+const (
+	// ElementNode is the node type of an element.
+	ElementNode = 1
+	// TextNode is the node type of text.
+	TextNode = 3
+	// CommentNode is the node type of a comment.
+	CommentNode = 8
+)
+
+// KeyAttr is the attribute which identifies an element among its siblings
+// when patching. See PatchElement.
+const KeyAttr = "data-key"
+
+// Node is a node in the DOM, e.g. an element, text, or a comment. It is
+// the part of the DOM that PatchElement and InsertElement depend on.
+// WrapNode returns a Node for the current backend, and a fakedom.Node
+// satisfies Node.
+type Node interface {
+	// NodeType returns the type of the node, e.g. ElementNode.
+	NodeType() int
+	// NodeName returns the name of the node, which for an element is its
+	// tag name.
+	NodeName() string
+	// NodeValue returns the text of a text node or comment.
+	NodeValue() string
+	// SetNodeValue sets the text of a text node or comment.
+	SetNodeValue(value string)
+	// ChildNodes returns the children of the node.
+	ChildNodes() []Node
+	// ParentNode returns the parent of the node, or nil if it has none.
+	ParentNode() Node
+	// NextSibling returns the node right after the node, or nil if it is
+	// the last child of its parent or has no parent.
+	NextSibling() Node
+	// InsertBefore inserts node as a child right before ref, or as the
+	// last child if ref is nil. If node is already in the DOM, it is
+	// moved.
+	InsertBefore(node, ref Node)
+	// RemoveChild removes the child node.
+	RemoveChild(node Node)
+	// Attributes returns the attributes of an element.
+	Attributes() map[string]string
+	// GetAttribute returns the value of the attribute with the given name,
+	// or an empty string if the element does not have it.
+	GetAttribute(name string) string
+	// SetAttribute sets the value of the attribute with the given name.
+	SetAttribute(name, value string)
+	// RemoveAttribute removes the attribute with the given name.
+	RemoveAttribute(name string)
+	// ParseHTML parses html in the context of the node, i.e. as if it was
+	// the innerHTML of the node, and returns the resulting nodes without
+	// adding them to the DOM.
+	ParseHTML(html string) []Node
+}
+
+// PatchElement executes an Executor with the given data and then updates
+// the children of el so that they match the result. Unlike ExecuteElement,
+// which replaces the innerHTML of el, PatchElement keeps every existing
+// node that it can and only changes what is different, so that focus,
+// cursor position, scroll position, transitions, and event listeners are
+// not lost. Only attributes are patched, so properties such as the value
+// of an input which the user has typed into are left alone.
+//
+// Children are matched up in order, except for elements with a data-key
+// attribute (see KeyAttr), which are matched with the existing element
+// that has the same key, wherever it is. Give each item in a list a unique
+// key so that adding, removing, or moving items doesn't cause the other
+// items to be patched.
+func PatchElement(e Executor, el Node, data interface{}) error {
+	html, err := executeToString(e, data)
+	if err != nil {
+		return err
+	}
+	patchChildren(el, el.ParseHTML(html))
+	return nil
+}
+
+// patchNode updates old so that it matches new. old and new must have the
+// same type and name.
+func patchNode(old, new Node) {
+	switch old.NodeType() {
+	case ElementNode:
+		newAttrs := new.Attributes()
+		for name := range old.Attributes() {
+			if _, found := newAttrs[name]; !found {
+				old.RemoveAttribute(name)
+			}
+		}
+		for name, value := range newAttrs {
+			if old.GetAttribute(name) != value {
+				old.SetAttribute(name, value)
+			}
+		}
+		patchChildren(old, new.ChildNodes())
+	default:
+		if old.NodeValue() != new.NodeValue() {
+			old.SetNodeValue(new.NodeValue())
+		}
+	}
+}
+
+// patchChildren updates the children of parent so that they match
+// children, which are not in the DOM.
+func patchChildren(parent Node, children []Node) {
+	oldChildren := parent.ChildNodes()
+	keyed := map[string]int{}
+	for i, old := range oldChildren {
+		if key := nodeKey(old); key != "" {
+			if _, found := keyed[key]; !found {
+				keyed[key] = i
+			}
+		}
+	}
+	// result holds the nodes which parent should end up with, in order, and
+	// matches maps the index of each old child that is kept to its index in
+	// result.
+	result := make([]Node, len(children))
+	matches := map[int]int{}
+	next := 0
+	for i, child := range children {
+		result[i] = child
+		match := -1
+		if key := nodeKey(child); key != "" {
+			if j, found := keyed[key]; found && sameNode(oldChildren[j], child) {
+				if _, used := matches[j]; !used {
+					match = j
+				}
+			}
+		} else {
+			// Match the first unkeyed old child of the same kind which comes
+			// after the previous match.
+			for j := next; j < len(oldChildren); j++ {
+				if _, used := matches[j]; used || nodeKey(oldChildren[j]) != "" {
+					continue
+				}
+				if sameNode(oldChildren[j], child) {
+					match = j
+					next = j + 1
+					break
+				}
+			}
+		}
+		if match != -1 {
+			matches[match] = i
+			patchNode(oldChildren[match], child)
+			result[i] = oldChildren[match]
+		}
+	}
+	// current holds the indexes in result of the children that parent has,
+	// in order.
+	current := []int{}
+	for j, old := range oldChildren {
+		if i, used := matches[j]; used {
+			current = append(current, i)
+		} else {
+			parent.RemoveChild(old)
+		}
+	}
+	for i, node := range result {
+		if i < len(current) && current[i] == i {
+			continue
+		}
+		var ref Node
+		if i < len(current) {
+			ref = result[current[i]]
+		}
+		parent.InsertBefore(node, ref)
+		current = moveIndex(current, i)
+	}
+}
+
+// moveIndex removes i from current, if it is there, and then inserts it at
+// position i.
+func moveIndex(current []int, i int) []int {
+	for j, index := range current {
+		if index == i {
+			current = append(current[:j], current[j+1:]...)
+			break
+		}
+	}
+	current = append(current, 0)
+	copy(current[i+1:], current[i:])
+	current[i] = i
+	return current
+}
+
+// sameNode returns true if old can be patched to match new.
+func sameNode(old, new Node) bool {
+	return old.NodeType() == new.NodeType() && old.NodeName() == new.NodeName() && nodeKey(old) == nodeKey(new)
+}
+
+// nodeKey returns the value of the KeyAttr attribute of node, or an empty
+// string if node is not an element or has no key.
+func nodeKey(node Node) string {
+	if node.NodeType() != ElementNode {
+		return ""
+	}
+	return node.GetAttribute(KeyAttr)
+}
@@ -0,0 +1,209 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+)
+
+// parseTreesLiteral parses src and returns go code for a
+// map[string]*parse.Tree literal which holds the tree for the template
+// itself under name, as well as the trees for any templates it defines.
+// The code is meant to be passed to AddTemplateTrees, AddPartialTrees,
+// or AddLayoutTrees in generated code. funcs only needs to hold the
+// names of any functions called in src.
+func parseTreesLiteral(name, src string, funcs template.FuncMap) (string, error) {
+	trees, err := parseSource(name, src, funcs)
+	if err != nil {
+		return "", err
+	}
+	treeNames := []string{}
+	for treeName := range trees {
+		treeNames = append(treeNames, treeName)
+	}
+	// Sort the names so the generated code is always the same for
+	// the same source.
+	sort.Strings(treeNames)
+	e := &treeEncoder{}
+	e.printf("map[string]*parse.Tree{\n")
+	for _, treeName := range treeNames {
+		tree := trees[treeName]
+		e.printf("%s: &parse.Tree{\nName: %s,\nParseName: %s,\nRoot: ", strconv.Quote(treeName), strconv.Quote(tree.Name), strconv.Quote(tree.ParseName))
+		e.list(tree.Root)
+		e.printf(",\n},\n")
+	}
+	e.printf("}")
+	if e.err != nil {
+		return "", e.err
+	}
+	return e.buf.String(), nil
+}
+
+// treeEncoder writes go code which reconstructs the nodes of a parse
+// tree. The positions of the nodes are not written, because they refer
+// to the original source, which is not included in the generated code.
+// As a result, errors that occur while executing precompiled templates
+// will not include line numbers.
+type treeEncoder struct {
+	buf bytes.Buffer
+	err error
+}
+
+// printf writes to the underlying buffer.
+func (e *treeEncoder) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&e.buf, format, args...)
+}
+
+// node writes the code for an arbitrary node.
+func (e *treeEncoder) node(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		e.list(n)
+	case *parse.TextNode:
+		e.printf("&parse.TextNode{NodeType: parse.NodeText, Text: []byte(%s)}", strconv.Quote(string(n.Text)))
+	case *parse.CommentNode:
+		e.printf("&parse.CommentNode{NodeType: parse.NodeComment, Text: %s}", strconv.Quote(n.Text))
+	case *parse.ActionNode:
+		e.printf("&parse.ActionNode{NodeType: parse.NodeAction, Line: %d, Pipe: ", n.Line)
+		e.pipe(n.Pipe)
+		e.printf("}")
+	case *parse.PipeNode:
+		e.pipe(n)
+	case *parse.CommandNode:
+		e.command(n)
+	case *parse.IdentifierNode:
+		e.printf("&parse.IdentifierNode{NodeType: parse.NodeIdentifier, Ident: %s}", strconv.Quote(n.Ident))
+	case *parse.VariableNode:
+		e.printf("&parse.VariableNode{NodeType: parse.NodeVariable, Ident: %s}", stringsLiteral(n.Ident))
+	case *parse.DotNode:
+		e.printf("&parse.DotNode{NodeType: parse.NodeDot}")
+	case *parse.NilNode:
+		e.printf("&parse.NilNode{NodeType: parse.NodeNil}")
+	case *parse.FieldNode:
+		e.printf("&parse.FieldNode{NodeType: parse.NodeField, Ident: %s}", stringsLiteral(n.Ident))
+	case *parse.ChainNode:
+		e.printf("&parse.ChainNode{NodeType: parse.NodeChain, Node: ")
+		e.node(n.Node)
+		e.printf(", Field: %s}", stringsLiteral(n.Field))
+	case *parse.BoolNode:
+		e.printf("&parse.BoolNode{NodeType: parse.NodeBool, True: %t}", n.True)
+	case *parse.NumberNode:
+		e.printf("&parse.NumberNode{NodeType: parse.NodeNumber, IsInt: %t, IsUint: %t, IsFloat: %t, IsComplex: %t, Int64: %d, Uint64: %d, Float64: %s, Complex128: complex(%s, %s), Text: %s}",
+			n.IsInt, n.IsUint, n.IsFloat, n.IsComplex, n.Int64, n.Uint64,
+			floatLiteral(n.Float64), floatLiteral(real(n.Complex128)), floatLiteral(imag(n.Complex128)),
+			strconv.Quote(n.Text))
+	case *parse.StringNode:
+		e.printf("&parse.StringNode{NodeType: parse.NodeString, Quoted: %s, Text: %s}", strconv.Quote(n.Quoted), strconv.Quote(n.Text))
+	case *parse.IfNode:
+		e.printf("&parse.IfNode{BranchNode: ")
+		e.branch("parse.NodeIf", &n.BranchNode)
+		e.printf("}")
+	case *parse.RangeNode:
+		e.printf("&parse.RangeNode{BranchNode: ")
+		e.branch("parse.NodeRange", &n.BranchNode)
+		e.printf("}")
+	case *parse.WithNode:
+		e.printf("&parse.WithNode{BranchNode: ")
+		e.branch("parse.NodeWith", &n.BranchNode)
+		e.printf("}")
+	case *parse.TemplateNode:
+		e.printf("&parse.TemplateNode{NodeType: parse.NodeTemplate, Line: %d, Name: %s, Pipe: ", n.Line, strconv.Quote(n.Name))
+		e.pipe(n.Pipe)
+		e.printf("}")
+	case *parse.BreakNode:
+		e.printf("&parse.BreakNode{NodeType: parse.NodeBreak, Line: %d}", n.Line)
+	case *parse.ContinueNode:
+		e.printf("&parse.ContinueNode{NodeType: parse.NodeContinue, Line: %d}", n.Line)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("temple: cannot precompile node of type %T: %s", node, node)
+		}
+		e.printf("nil")
+	}
+}
+
+// list writes the code for a list node, which may be nil.
+func (e *treeEncoder) list(list *parse.ListNode) {
+	if list == nil {
+		e.printf("nil")
+		return
+	}
+	e.printf("&parse.ListNode{NodeType: parse.NodeList, Nodes: []parse.Node{\n")
+	for _, node := range list.Nodes {
+		e.node(node)
+		e.printf(",\n")
+	}
+	e.printf("}}")
+}
+
+// pipe writes the code for a pipe node, which may be nil.
+func (e *treeEncoder) pipe(pipe *parse.PipeNode) {
+	if pipe == nil {
+		e.printf("nil")
+		return
+	}
+	e.printf("&parse.PipeNode{NodeType: parse.NodePipe, Line: %d, IsAssign: %t, ", pipe.Line, pipe.IsAssign)
+	if len(pipe.Decl) > 0 {
+		e.printf("Decl: []*parse.VariableNode{")
+		for _, decl := range pipe.Decl {
+			e.node(decl)
+			e.printf(", ")
+		}
+		e.printf("}, ")
+	}
+	e.printf("Cmds: []*parse.CommandNode{")
+	for _, cmd := range pipe.Cmds {
+		e.command(cmd)
+		e.printf(", ")
+	}
+	e.printf("}}")
+}
+
+// command writes the code for a command node.
+func (e *treeEncoder) command(cmd *parse.CommandNode) {
+	e.printf("&parse.CommandNode{NodeType: parse.NodeCommand, Args: []parse.Node{")
+	for _, arg := range cmd.Args {
+		e.node(arg)
+		e.printf(", ")
+	}
+	e.printf("}}")
+}
+
+// branch writes the code for the BranchNode embedded in an if, range,
+// or with node. nodeType is the name of the corresponding NodeType
+// constant.
+func (e *treeEncoder) branch(nodeType string, branch *parse.BranchNode) {
+	e.printf("parse.BranchNode{NodeType: %s, Line: %d, Pipe: ", nodeType, branch.Line)
+	e.pipe(branch.Pipe)
+	e.printf(", List: ")
+	e.list(branch.List)
+	e.printf(", ElseList: ")
+	e.list(branch.ElseList)
+	e.printf("}")
+}
+
+// stringsLiteral returns go code for a []string literal holding strs.
+func stringsLiteral(strs []string) string {
+	buf := bytes.NewBufferString("[]string{")
+	for i, s := range strs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(strconv.Quote(s))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// floatLiteral returns go code for the constant f.
+func floatLiteral(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,279 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// refChecker checks the `template` actions in a group. See checkTemplateRefs.
+type refChecker struct {
+	g *Group
+	// usedPartials and usedLayouts hold the names of the partials and
+	// layouts which are used by some regular template, without prefixes.
+	usedPartials map[string]bool
+	usedLayouts  map[string]bool
+	errs         map[string]bool
+}
+
+// checkTemplateRefs checks that every template called with the `template`
+// action in g exists. Any template called by a regular template, directly
+// or through the templates it calls, must be associated with it, and any
+// partial or layout called by name from anywhere must exist. Partials which
+// call each other in a cycle without any condition, which would never stop
+// when executed, are also reported. g must already be compiled, and its
+// trees should have been added with their ParseName set to the name of the
+// file they came from, just like for checkTemplateData. If any errors are
+// found, they are all returned together. Partials and layouts which are not
+// used by any regular template are not errors, since they can still be
+// executed directly, but a warning with the location of each one is
+// returned in unused.
+func checkTemplateRefs(g *Group) (unused []string, err error) {
+	c := &refChecker{
+		g:            g,
+		usedPartials: map[string]bool{},
+		usedLayouts:  map[string]bool{},
+		errs:         map[string]bool{},
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for name, template := range g.templates {
+		c.checkTemplate(name, template)
+	}
+	for _, kind := range []map[string]map[string]*parse.Tree{g.partialTrees, g.layoutTrees} {
+		for _, trees := range kind {
+			for _, tree := range trees {
+				c.checkPrefixedRefs(tree)
+			}
+		}
+	}
+	c.checkPartialCycles()
+	for name, trees := range g.partialTrees {
+		if !c.usedPartials[name] {
+			unused = append(unused, unusedWarning(prefixedName(PartialPrefix, name), trees[name]))
+		}
+	}
+	for name, trees := range g.layoutTrees {
+		if !c.usedLayouts[name] {
+			unused = append(unused, unusedWarning(prefixedName(LayoutPrefix, name), trees[name]))
+		}
+	}
+	sort.Strings(unused)
+	if len(c.errs) == 0 {
+		return unused, nil
+	}
+	msgs := []string{}
+	for msg := range c.errs {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	return unused, errors.New(strings.Join(msgs, "\n"))
+}
+
+// checkTemplate checks the regular template identified by name, which has
+// been compiled to template. Each tree defined in the file for the template
+// is checked, along with every tree it calls.
+func (c *refChecker) checkTemplate(name string, template *Template) {
+	set := associatedTrees(template.Template, template.Text)
+	// Any layouts which the template extends with a layout directive are
+	// used, even though they are not called by name.
+	extended := map[string]bool{}
+	layout := findDirective(c.g.templateTrees[name][name], layoutDirective)
+	for layout != "" && !extended[layout] {
+		extended[layout] = true
+		layout = strings.TrimPrefix(layout, LayoutPrefix)
+		c.usedLayouts[layout] = true
+		layout = findDirective(c.g.layoutTrees[layout][layout], layoutDirective)
+	}
+	checked := map[string]bool{}
+	var check func(tree *parse.Tree)
+	check = func(tree *parse.Tree) {
+		if checked[tree.Name] {
+			return
+		}
+		checked[tree.Name] = true
+		walkTemplateNodes(tree.Root, false, func(n *parse.TemplateNode, conditional bool) {
+			called, found := set[n.Name]
+			if !found {
+				c.errorf(tree, n, "no such template %q", n.Name)
+				return
+			}
+			c.markUsed(n.Name)
+			check(called)
+		})
+	}
+	check(set[name])
+	for treeName := range c.g.templateTrees[name] {
+		if tree, found := set[treeName]; found {
+			check(tree)
+		}
+	}
+}
+
+// checkPrefixedRefs checks that any partial or layout called by name in
+// tree exists. Partials are associated with every template, so these calls
+// can be checked without knowing which regular templates use tree.
+func (c *refChecker) checkPrefixedRefs(tree *parse.Tree) {
+	walkTemplateNodes(tree.Root, false, func(n *parse.TemplateNode, conditional bool) {
+		var found bool
+		switch {
+		case strings.HasPrefix(n.Name, PartialPrefix):
+			_, found = c.g.partialTrees[strings.TrimPrefix(n.Name, PartialPrefix)]
+		case strings.HasPrefix(n.Name, LayoutPrefix):
+			_, found = c.g.layoutTrees[strings.TrimPrefix(n.Name, LayoutPrefix)]
+		default:
+			return
+		}
+		if !found {
+			c.errorf(tree, n, "no such template %q", n.Name)
+		}
+	})
+}
+
+// checkPartialCycles reports any partials which call each other in a cycle
+// without any condition. Calls inside an if, range, or with action are
+// ignored, since recursive partials are fine as long as they stop at some
+// point.
+func (c *refChecker) checkPartialCycles() {
+	// calls holds the partials called by each partial without any
+	// condition, along with the node for each call.
+	calls := map[string]map[string]*parse.TemplateNode{}
+	for name, trees := range c.g.partialTrees {
+		calls[name] = map[string]*parse.TemplateNode{}
+		walkTemplateNodes(trees[name].Root, false, func(n *parse.TemplateNode, conditional bool) {
+			if !conditional && strings.HasPrefix(n.Name, PartialPrefix) {
+				calls[name][strings.TrimPrefix(n.Name, PartialPrefix)] = n
+			}
+		})
+	}
+	names := []string{}
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Each cycle is only reported once, starting from the partial in it
+	// with the lowest name, so only partials with higher names are visited
+	// after start.
+	var start string
+	var visit func(path []string)
+	visit = func(path []string) {
+		caller := path[len(path)-1]
+		for _, called := range names {
+			n, found := calls[caller][called]
+			if !found || called < start {
+				continue
+			}
+			if called == start {
+				cycle := []string{}
+				for _, member := range append(path, start) {
+					cycle = append(cycle, prefixedName(PartialPrefix, member))
+				}
+				c.errorf(c.g.partialTrees[caller][caller], n, "partials call each other in a cycle which never stops: %s", strings.Join(cycle, " -> "))
+				continue
+			}
+			visiting := false
+			for _, member := range path {
+				visiting = visiting || member == called
+			}
+			if !visiting {
+				visit(append(path, called))
+			}
+		}
+	}
+	for _, start = range names {
+		visit([]string{start})
+	}
+}
+
+// markUsed records that the template identified by name is called by some
+// regular template if it is a partial or layout.
+func (c *refChecker) markUsed(name string) {
+	if strings.HasPrefix(name, PartialPrefix) {
+		c.usedPartials[strings.TrimPrefix(name, PartialPrefix)] = true
+	} else if strings.HasPrefix(name, LayoutPrefix) {
+		c.usedLayouts[strings.TrimPrefix(name, LayoutPrefix)] = true
+	}
+}
+
+// errorf records an error at the location of node in tree.
+func (c *refChecker) errorf(tree *parse.Tree, node parse.Node, format string, args ...interface{}) {
+	c.errs[errorLocation(tree, node)+": "+fmt.Sprintf(format, args...)] = true
+}
+
+// unusedWarning returns the warning for the partial or layout identified by
+// name, which is not used by any template. tree is the tree for the partial
+// or layout itself, and the warning starts with its location if it is not
+// nil.
+func unusedWarning(name string, tree *parse.Tree) string {
+	warning := fmt.Sprintf("%s is not used by any template", name)
+	if tree == nil || tree.Root == nil {
+		return warning
+	}
+	return errorLocation(tree, tree.Root) + ": " + warning
+}
+
+// errorLocation returns the location of node in tree in the form
+// "filename:line", using the ParseName of tree as the filename.
+func errorLocation(tree *parse.Tree, node parse.Node) string {
+	location, _ := tree.ErrorContext(node)
+	// location has the form "filename:line:column". Leave out the column.
+	if i := strings.LastIndex(location, ":"); i != -1 {
+		location = location[:i]
+	}
+	return location
+}
+
+// associatedTrees returns the trees for every template associated with
+// html or text, whichever is not nil, by name.
+func associatedTrees(html *template.Template, text *texttemplate.Template) map[string]*parse.Tree {
+	set := map[string]*parse.Tree{}
+	if text != nil {
+		for _, t := range text.Templates() {
+			if t.Tree != nil {
+				set[t.Name()] = t.Tree
+			}
+		}
+	} else {
+		for _, t := range html.Templates() {
+			if t.Tree != nil {
+				set[t.Name()] = t.Tree
+			}
+		}
+	}
+	return set
+}
+
+// walkTemplateNodes calls f for each `template` action in node and the
+// nodes it contains. conditional is true if node is inside an if, range,
+// or with action, and is passed on to f.
+func walkTemplateNodes(node parse.Node, conditional bool, f func(n *parse.TemplateNode, conditional bool)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplateNodes(child, conditional, f)
+		}
+	case *parse.IfNode:
+		walkTemplateNodes(n.List, true, f)
+		walkTemplateNodes(n.ElseList, true, f)
+	case *parse.RangeNode:
+		walkTemplateNodes(n.List, true, f)
+		walkTemplateNodes(n.ElseList, true, f)
+	case *parse.WithNode:
+		walkTemplateNodes(n.List, true, f)
+		walkTemplateNodes(n.ElseList, true, f)
+	case *parse.TemplateNode:
+		f(n, conditional)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template/parse"
+)
+
+func TestCheckTemplateRefs(t *testing.T) {
+	testCases := []struct {
+		templates map[string]string
+		partials  map[string]string
+		layouts   map[string]string
+		expected  []string
+		unused    []string
+	}{
+		{
+			// Partials and layouts called from templates or extended with a
+			// layout directive are used, and recursion inside a condition is
+			// fine.
+			templates: map[string]string{
+				"index": `{{ define "content" }}{{ template "partials/list" . }}{{ end }}{{ template "layouts/app" . }}`,
+				"show":  `{{/* temple:layout page */}}{{ define "content" }}{{ template "partials/item" . }}{{ end }}`,
+			},
+			partials: map[string]string{
+				"list": `{{ range . }}{{ template "partials/item" . }}{{ end }}`,
+				"item": `{{ .Name }}{{ if .Children }}{{ template "partials/list" .Children }}{{ end }}`,
+			},
+			layouts: map[string]string{
+				"app":  `<body>{{ template "content" . }}</body>`,
+				"page": `{{/* temple:layout app */}}{{ define "content" }}{{ end }}`,
+			},
+		},
+		{
+			templates: map[string]string{
+				"index": "{{ template \"partials/missing\" }}\n{{ define \"title\" }}{{ template \"subtitle\" }}{{ end }}",
+				"about": `{{ template "layouts/app" }}`,
+			},
+			partials: map[string]string{
+				"unused": "\n{{ template \"layouts/missing\" }}",
+			},
+			layouts: map[string]string{
+				"app": "<body>\n{{ template \"content\" . }}</body>",
+			},
+			expected: []string{
+				`layouts/app.tmpl:2: no such template "content"`,
+				`partials/unused.tmpl:2: no such template "layouts/missing"`,
+				`templates/index.tmpl:1: no such template "partials/missing"`,
+				`templates/index.tmpl:2: no such template "subtitle"`,
+			},
+			unused: []string{"partials/unused.tmpl:1: partials/unused is not used by any template"},
+		},
+		{
+			// Partials which call each other without a condition never stop.
+			templates: map[string]string{
+				"index": `{{ template "partials/a" }}`,
+			},
+			partials: map[string]string{
+				"a": `{{ template "partials/b" }}`,
+				"b": "{{ with . }}{{ end }}\n{{ template \"partials/a\" }}",
+				"c": `{{ template "partials/c" }}`,
+			},
+			expected: []string{
+				`partials/b.tmpl:2: partials call each other in a cycle which never stops: partials/a -> partials/b -> partials/a`,
+				`partials/c.tmpl:1: partials call each other in a cycle which never stops: partials/c -> partials/c`,
+			},
+			unused: []string{"partials/c.tmpl:1: partials/c is not used by any template"},
+		},
+	}
+	for i, tc := range testCases {
+		g := NewGroup()
+		add := func(sources map[string]string, dir string, addTrees func(string, map[string]*parse.Tree) error) {
+			for name, src := range sources {
+				trees, err := parseSource(name, src, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, tree := range trees {
+					tree.ParseName = dir + "/" + name + ".tmpl"
+				}
+				if err := addTrees(name, trees); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		add(tc.templates, "templates", g.AddTemplateTrees)
+		add(tc.partials, "partials", g.AddPartialTrees)
+		add(tc.layouts, "layouts", g.AddLayoutTrees)
+		if err := g.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		unused, err := checkTemplateRefs(g)
+		if !reflect.DeepEqual(unused, tc.unused) {
+			t.Errorf("Unused partials and layouts for test case %d were not correct.\nExpected: %v\nBut got:  %v", i, tc.unused, unused)
+		}
+		if len(tc.expected) == 0 {
+			if err != nil {
+				t.Errorf("Unexpected error in checkTemplateRefs for test case %d: %s", i, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Expected an error in checkTemplateRefs for test case %d but got none", i)
+			continue
+		}
+		if err.Error() != strings.Join(tc.expected, "\n") {
+			t.Errorf("Errors from checkTemplateRefs for test case %d were not correct.\nExpected:\n%s\nBut got:\n%s", i, strings.Join(tc.expected, "\n"), err.Error())
+		}
+	}
+}
+
+func TestBuildChecksTemplateRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-refs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templates := filepath.Join(dir, "templates")
+	writeTestFile(t, filepath.Join(templates, "index.tmpl"), "<ul>\n{{ template \"partials/todo\" . }}\n</ul>")
+	err = Build(templates, filepath.Join(dir, "templates.go"), "", "", "main")
+	if err == nil {
+		t.Fatal("Expected an error in Build for a template which calls a partial that does not exist but got none")
+	}
+	expected := filepath.Join(templates, "index.tmpl") + `:2: no such template "partials/todo"`
+	if err.Error() != expected {
+		t.Errorf("Error from Build was not correct.\nExpected: %s\nBut got:  %s", expected, err.Error())
+	}
+}
+
+func TestBuildStrict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templates := filepath.Join(dir, "templates")
+	partials := filepath.Join(dir, "partials")
+	writeTestFile(t, filepath.Join(templates, "index.tmpl"), "<ul></ul>")
+	writeTestFile(t, filepath.Join(partials, "todo.tmpl"), "<li>{{ . }}</li>")
+	warning := filepath.Join(partials, "todo.tmpl") + ":1: partials/todo is not used by any template"
+	cfg := BuildConfig{
+		Src:      templates,
+		Dest:     filepath.Join(dir, "templates.go"),
+		Partials: partials,
+		Package:  "main",
+		DryRun:   true,
+		Log:      ioutil.Discard,
+	}
+	result, err := BuildWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error in BuildWithConfig without Strict: %s", err.Error())
+	}
+	if !reflect.DeepEqual(result.Warnings, []string{warning}) {
+		t.Errorf("Warnings were not correct.\nExpected: %v\nBut got:  %v", []string{warning}, result.Warnings)
+	}
+	cfg.Strict = true
+	if _, err := BuildWithConfig(cfg); err == nil {
+		t.Error("Expected an error in BuildWithConfig with Strict for an unused partial but got none")
+	} else if !strings.Contains(err.Error(), warning) {
+		t.Errorf("Expected the error from BuildWithConfig with Strict to contain %q but got: %s", warning, err.Error())
+	}
+	err = Check(templates, "", partials, "", "main", WithStrict(), WithLog(ioutil.Discard))
+	if err == nil {
+		t.Error("Expected an error in Check with WithStrict for an unused partial but got none")
+	}
+}
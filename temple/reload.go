@@ -0,0 +1,176 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"sync"
+	"text/template/parse"
+	"time"
+)
+
+// sourceKind is the kind of template a groupSource is added as.
+type sourceKind int
+
+const (
+	templateKind sourceKind = iota
+	partialKind
+	layoutKind
+)
+
+// groupSource holds everything needed to add a regular template, partial,
+// or layout to a group. Exactly one of src, trees, or filename is set by
+// the caller. If filename is set, src, modTime, and size are set when the
+// file is read. If fsys is set, filename is a path in fsys instead of the OS
+// filesystem.
+type groupSource struct {
+	kind     sourceKind
+	name     string
+	src      string
+	trees    map[string]*parse.Tree
+	filename string
+	fsys     fs.FS
+	modTime  time.Time
+	size     int64
+}
+
+// stat returns the FileInfo for the file for source.
+func (source *groupSource) stat() (os.FileInfo, error) {
+	if source.fsys != nil {
+		return fs.Stat(source.fsys, source.filename)
+	}
+	return os.Stat(source.filename)
+}
+
+// read reads the contents of the file for source and records its
+// modification time and size.
+func (source *groupSource) read() error {
+	info, err := source.stat()
+	if err != nil {
+		return err
+	}
+	var src []byte
+	if source.fsys != nil {
+		src, err = fs.ReadFile(source.fsys, source.filename)
+	} else {
+		src, err = ioutil.ReadFile(source.filename)
+	}
+	if err != nil {
+		return err
+	}
+	source.src = string(src)
+	source.modTime = info.ModTime()
+	source.size = info.Size()
+	return nil
+}
+
+// changed returns true iff source was read from a file which has
+// been changed or removed since. The size is compared as well as the
+// modification time, since on filesystems with coarse modification times
+// a change made right after the file was read may not change it.
+func (source *groupSource) changed() bool {
+	if source.filename == "" {
+		return false
+	}
+	info, err := source.stat()
+	if err != nil {
+		return true
+	}
+	return !info.ModTime().Equal(source.modTime) || info.Size() != source.size
+}
+
+// reloader holds the state needed to reload a group.
+type reloader struct {
+	// mu serializes adding templates and reloading, and protects sources.
+	mu sync.Mutex
+	// sources holds everything that has been added to the group, in
+	// order.
+	sources []*groupSource
+}
+
+// EnableReload causes the group to check whether any of the files that
+// its templates, partials, or layouts were loaded from have changed each
+// time a template, partial, or layout is requested. If any of them have,
+// everything in the group is added again, so that a change to a partial
+// or layout is also reflected in every template it is associated with.
+// Files are only checked if they were added with AddTemplateFile,
+// AddPartialFile, AddLayoutFile, or one of the methods which call them,
+// such as AddAllFiles. Files added from an fs.FS with AddTemplatesFS or
+// one of the similar methods are also checked, using the modification
+// times reported by the fs.FS. New files are not picked up.
+//
+// Reloading is meant for development. It makes requesting templates
+// slower, and a template that fails to compile after a change will cause
+// the Get methods to return an error (and the MustGet methods to panic)
+// until it is fixed. EnableReload must be called before anything is added
+// to the group.
+func (g *Group) EnableReload() {
+	g.reload = &reloader{}
+}
+
+// recordSource records source so that it can be added again when
+// reloading, if reloading is enabled. The caller must hold g.reload.mu.
+func (g *Group) recordSource(source *groupSource) {
+	if g.reload == nil {
+		return
+	}
+	g.reload.sources = append(g.reload.sources, source)
+}
+
+// reloadIfChanged adds everything in the group again if reloading is
+// enabled and any of the files that were added have changed. If adding
+// anything fails, the group is left as it was and the error is returned.
+func (g *Group) reloadIfChanged() error {
+	if g.reload == nil {
+		return nil
+	}
+	g.reload.mu.Lock()
+	defer g.reload.mu.Unlock()
+	changed := false
+	for _, source := range g.reload.sources {
+		if source.changed() {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+	fresh := NewGroup()
+	fresh.mode = g.mode
+	g.mu.RLock()
+	for name, f := range g.Funcs {
+		fresh.Funcs[name] = f
+	}
+	g.mu.RUnlock()
+	sources := make([]*groupSource, len(g.reload.sources))
+	for i, source := range g.reload.sources {
+		// Copy the source so that it is left untouched if reloading fails.
+		copied := *source
+		if err := fresh.add(&copied); err != nil {
+			return err
+		}
+		sources[i] = &copied
+	}
+	if err := fresh.Compile(); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.templates = fresh.templates
+	g.partials = fresh.partials
+	g.layouts = fresh.layouts
+	g.layered = fresh.layered
+	g.templateTrees = fresh.templateTrees
+	g.partialTrees = fresh.partialTrees
+	g.layoutTrees = fresh.layoutTrees
+	g.stale = fresh.stale
+	g.allStale = fresh.allStale
+	g.mu.Unlock()
+	g.reload.sources = sources
+	return nil
+}
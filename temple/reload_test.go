@@ -0,0 +1,123 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"templates/index.tmpl":   `{{ define "content" }}{{ template "partials/greeting" . }}{{ end }}{{ template "layouts/app" . }}`,
+		"partials/greeting.tmpl": `Hello, {{ . }}!`,
+		"layouts/app.tmpl":       `<body>{{ template "content" . }}</body>`,
+	}
+	for filename, src := range files {
+		writeTestFile(t, filepath.Join(dir, filename), src)
+	}
+	g := NewGroup()
+	g.EnableReload()
+	if err := g.AddAllFiles(filepath.Join(dir, "templates"), filepath.Join(dir, "partials"), filepath.Join(dir, "layouts")); err != nil {
+		t.Fatalf("Unexpected error in AddAllFiles: %s", err.Error())
+	}
+	// Templates which were not loaded from files should also keep working
+	// after a reload.
+	if err := g.AddTemplate("inline", `{{ template "partials/greeting" . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, g.MustGetTemplate("index"), "world", "<body>Hello, world!</body>")
+	// Changing the partial should be reflected in the templates which use it,
+	// even though they have already been executed.
+	writeTestFile(t, filepath.Join(dir, "partials/greeting.tmpl"), `Goodbye, {{ . }}!`)
+	expectExecutorOutputs(t, g.MustGetTemplate("index"), "world", "<body>Goodbye, world!</body>")
+	expectExecutorOutputs(t, g.MustGetTemplate("inline"), "world", "Goodbye, world!")
+	// A template which fails to compile should cause an error until it
+	// is fixed.
+	writeTestFile(t, filepath.Join(dir, "layouts/app.tmpl"), `<body>{{ template "content" . </body>`)
+	if _, err := g.GetTemplate("index"); err == nil {
+		t.Error("Expected an error in GetTemplate after a layout was broken but got none")
+	}
+	writeTestFile(t, filepath.Join(dir, "layouts/app.tmpl"), `<main>{{ template "content" . }}</main>`)
+	indexTmpl, err := g.GetTemplate("index")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, indexTmpl, "world", "<main>Goodbye, world!</main>")
+}
+
+func TestReloadSameModTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "index.tmpl")
+	writeTestFile(t, filename, `Hello, {{ . }}!`)
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGroup()
+	g.EnableReload()
+	if err := g.AddTemplateFile("index", filename); err != nil {
+		t.Fatalf("Unexpected error in AddTemplateFile: %s", err.Error())
+	}
+	// Simulate a filesystem with coarse modification times, where a change
+	// made right after the file was read does not change its modification
+	// time.
+	writeTestFile(t, filename, `Goodbye, {{ . }}!`)
+	if err := os.Chtimes(filename, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	expectExecutorOutputs(t, g.MustGetTemplate("index"), "world", "Goodbye, world!")
+}
+
+func TestNoReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "index.tmpl")
+	writeTestFile(t, filename, `Hello, {{ . }}!`)
+	g := NewGroup()
+	if err := g.AddTemplateFile("index", filename); err != nil {
+		t.Fatalf("Unexpected error in AddTemplateFile: %s", err.Error())
+	}
+	writeTestFile(t, filename, `Goodbye, {{ . }}!`)
+	expectExecutorOutputs(t, g.MustGetTemplate("index"), "world", "Hello, world!")
+}
+
+// writeTestFile writes src to filename, creating any directories as
+// needed. It also moves the modification time of the file forward, since
+// the resolution of the file system might be too low to notice a change
+// otherwise.
+func writeTestFile(t *testing.T, filename, src string) {
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	var modTime time.Time
+	if info, err := os.Stat(filename); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	} else {
+		modTime = time.Now()
+	}
+	if err := ioutil.WriteFile(filename, []byte(src), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
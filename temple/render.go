@@ -0,0 +1,107 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"fmt"
+	"go/types"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// dataImport is a package which is imported by generated code because
+// it declares the data type of some template.
+type dataImport struct {
+	Alias string
+	Path  string
+}
+
+// renderFuncName returns the name of the generated render function for
+// the regular template whose name constant is nameConst, e.g.
+// "RenderTodosIndex" for "TemplateTodosIndex". Since the name constants
+// are unique, so are the render functions.
+func renderFuncName(nameConst string) string {
+	return "Render" + strings.TrimPrefix(nameConst, "Template")
+}
+
+// addRenderFuncs sets RenderFunc and DataType for each regular template
+// and adds any packages which declare the data types to DataImports. The
+// data type is read from the data directive of the template, and is
+// interface{} if there is none. funcs only needs to hold the names of any
+// functions called by the templates. addNameConsts must be called first.
+func (data *templateData) addRenderFuncs(funcs map[string]interface{}) error {
+	for i, file := range data.Templates {
+		funcName := renderFuncName(file.NameConst)
+		trees, err := parseSource(file.Name, file.Src, funcs)
+		if err != nil {
+			return err
+		}
+		dataType := "interface{}"
+		if spec := findDirective(trees[file.Name], dataDirective); spec != "" {
+			if dataType, err = data.dataTypeExpr(spec); err != nil {
+				return err
+			}
+		}
+		data.Templates[i].RenderFunc = funcName
+		data.Templates[i].DataType = dataType
+	}
+	return nil
+}
+
+// dataTypeExpr returns go code for the type described by spec, which has
+// the form accepted by typeLoader.load. If the type is declared in another
+// package, the package is added to DataImports.
+func (data *templateData) dataTypeExpr(spec string) (string, error) {
+	for _, prefix := range []string{"*", "[]"} {
+		if strings.HasPrefix(spec, prefix) {
+			elem, err := data.dataTypeExpr(strings.TrimPrefix(spec, prefix))
+			if err != nil {
+				return "", err
+			}
+			return prefix + elem, nil
+		}
+	}
+	i := strings.LastIndex(spec, ".")
+	if i == -1 {
+		if _, ok := types.Universe.Lookup(spec).(*types.TypeName); !ok {
+			return "", fmt.Errorf("temple: could not find data type %s", spec)
+		}
+		return spec, nil
+	}
+	return data.importAlias(spec[:i]) + "." + spec[i+1:], nil
+}
+
+// importAlias returns the name that the package with the given import path
+// is imported with in generated code, adding it to DataImports if needed.
+// The alias is based on the last element of the path, and is changed if
+// it would collide with another import.
+func (data *templateData) importAlias(importPath string) string {
+	for _, imp := range data.DataImports {
+		if imp.Path == importPath {
+			return imp.Alias
+		}
+	}
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, path.Base(importPath))
+	if base == "" || unicode.IsDigit(rune(base[0])) {
+		base = "data" + base
+	}
+	taken := map[string]bool{"io": true, "parse": true, "temple": true, funcsAlias: true}
+	for _, imp := range data.DataImports {
+		taken[imp.Alias] = true
+	}
+	alias := base
+	for n := 2; taken[alias]; n++ {
+		alias = fmt.Sprintf("%s%d", base, n)
+	}
+	data.DataImports = append(data.DataImports, dataImport{Alias: alias, Path: importPath})
+	return alias
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"testing"
+)
+
+func TestRenderFuncName(t *testing.T) {
+	testCases := map[string]string{
+		"index":            "RenderIndex",
+		"todos/index":      "RenderTodosIndex",
+		"people/show-all":  "RenderPeopleShowAll",
+		"emails/2fa_code":  "RenderEmails2faCode",
+		"admin/users.edit": "RenderAdminUsersEdit",
+	}
+	for name, expected := range testCases {
+		if got := renderFuncName(exportedName("Template", name)); got != expected {
+			t.Errorf("Expected renderFuncName(%q) to be %s but got %s", name, expected, got)
+		}
+	}
+}
+
+func TestAddRenderFuncs(t *testing.T) {
+	data := &templateData{
+		Templates: []sourceFile{
+			{Name: "todos/index", Src: `{{/* temple:data []*github.com/me/app/models.Todo */}}`},
+			{Name: "people/show", Src: `{{/* temple:data github.com/me/other/models.Person */}}`},
+			{Name: "greeting", Src: `{{/* temple:data string */}}Hello, {{ . }}!`},
+			{Name: "about", Src: `About`},
+		},
+	}
+	data.addNameConsts()
+	if err := data.addRenderFuncs(nil); err != nil {
+		t.Fatalf("Unexpected error in addRenderFuncs: %s", err.Error())
+	}
+	expected := []string{"[]*models.Todo", "models2.Person", "string", "interface{}"}
+	for i, file := range data.Templates {
+		if file.DataType != expected[i] {
+			t.Errorf("Expected data type for %s to be %s but got %s", file.Name, expected[i], file.DataType)
+		}
+	}
+	if len(data.DataImports) != 2 || data.DataImports[0].Path != "github.com/me/app/models" || data.DataImports[1].Alias != "models2" {
+		t.Errorf("Data imports were not correct: %v", data.DataImports)
+	}
+	// Unknown predeclared types are an error.
+	data = &templateData{
+		Templates: []sourceFile{{Name: "index", Src: `{{/* temple:data strnig */}}`}},
+	}
+	data.addNameConsts()
+	if err := data.addRenderFuncs(nil); err == nil {
+		t.Error("Expected an error in addRenderFuncs for a data type which does not exist but got none")
+	}
+}
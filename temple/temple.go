@@ -9,10 +9,10 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
+	texttemplate "text/template"
+	"text/template/parse"
 )
 
 var (
@@ -29,9 +29,16 @@ var (
 // tree for all templates. So you can render partials and layouts
 // inside of a template with the `template` action. See
 // http://golang.org/pkg/text/template/ for more information
-// about nested templates and the `template` action.
+// about nested templates and the `template` action. If the template
+// belongs to a group which uses TextMode, the embedded Template is nil
+// and Text holds the template instead. Execute, ExecuteTemplate, Name,
+// and DefinedTemplates work in both modes, but the other methods and
+// fields of the embedded Template, e.g. Lookup, Clone, and Tree, return
+// html/template types and are only available in HTMLMode. In TextMode,
+// use the same methods and fields of Text instead.
 type Template struct {
 	*template.Template
+	Text *texttemplate.Template
 }
 
 // Partial is a lightweight wrapper around template.Template
@@ -42,9 +49,12 @@ type Template struct {
 // templates, layouts, or other partials with the
 // `template` action. See http://golang.org/pkg/text/template/
 // for more information about nested templates and the `template`
-// action.
+// action. Just like Template, Text holds the partial instead of
+// the embedded Template in TextMode, and only Execute, ExecuteTemplate,
+// Name, and DefinedTemplates work in both modes.
 type Partial struct {
 	*template.Template
+	Text *texttemplate.Template
 }
 
 // Layout is a lightweight wrapper around template.Template
@@ -55,27 +65,73 @@ type Partial struct {
 // the `template` action. You can also render a layout from inside
 // a regular template with the `template` action. See
 // http://golang.org/pkg/text/template/ for more information
-// about nested templates and the `template` action.
+// about nested templates and the `template` action. Just like
+// Template, Text holds the layout instead of the embedded Template in
+// TextMode, and only Execute, ExecuteTemplate, Name, and
+// DefinedTemplates work in both modes.
 type Layout struct {
 	*template.Template
+	Text *texttemplate.Template
 }
 
 // A Group represents a set of associated templates, partials, and layouts.
-// Each Group also gets its own template.FuncMap called Funcs.
+// Each Group also gets its own template.FuncMap called Funcs. Templates,
+// partials, and layouts can be added to a group in any order. The
+// associations between them are built when the group is compiled, which
+// happens automatically the first time anything is requested after
+// something has been added. A Group is safe for concurrent use, so templates
+// can be added while others are being executed. Adding a partial or layout
+// causes every template to be compiled again, so any template, partial, or
+// layout that was requested before will not see it. Get it again instead.
 type Group struct {
+	// mu protects the maps below, including Funcs. Templates which have
+	// been added to the group synchronize themselves.
+	mu        sync.RWMutex
 	templates map[string]*Template
 	partials  map[string]*Partial
 	layouts   map[string]*Layout
+	// layered holds the regular templates which have been compiled
+	// together with a layout by ExecuteWithLayout since the group was
+	// last compiled.
+	layered map[layeredKey]*Template
+	// templateTrees, partialTrees, and layoutTrees hold the parse trees
+	// for everything that has been added to the group, by name. For each
+	// name there is a tree for the template itself, as well as the trees
+	// for any templates it defines. The trees are never executed, so they
+	// can be copied and associated with each other when compiling.
+	templateTrees map[string]map[string]*parse.Tree
+	partialTrees  map[string]map[string]*parse.Tree
+	layoutTrees   map[string]map[string]*parse.Tree
+	// stale holds the names of the regular templates which have been added
+	// since the group was last compiled. If allStale is true, everything
+	// needs to be compiled again, e.g. because a partial was added.
+	stale    map[string]bool
+	allStale bool
+	// mode determines whether templates are compiled with html/template
+	// or text/template. It never changes after the group is created.
+	mode Mode
+	// reload holds the state needed to reload templates from files. It
+	// is nil unless EnableReload has been called.
+	reload *reloader
 	// Funcs is a map of function names to functions. All functions in the
 	// FuncMap are accessible by all templates, partials, and layouts for
-	// this Group.
+	// this Group. Use AddFunc instead of changing Funcs directly if the
+	// group might be in use by other goroutines.
 	Funcs template.FuncMap
 }
 
 // GetTemplate returns the template identified by name, or an error if
-// the template could not be found.
-func (g Group) GetTemplate(name string) (*Template, error) {
+// the template could not be found or the group could not be compiled.
+// The template is a snapshot of the group: it does not see any partials
+// or layouts that are added or changed afterwards, so call GetTemplate
+// again after adding them.
+func (g *Group) GetTemplate(name string) (*Template, error) {
+	if err := g.prepare(); err != nil {
+		return nil, err
+	}
+	g.mu.RLock()
 	template, found := g.templates[name]
+	g.mu.RUnlock()
 	if !found {
 		return nil, fmt.Errorf("Could not find template named %s", name)
 	}
@@ -83,9 +139,13 @@ func (g Group) GetTemplate(name string) (*Template, error) {
 }
 
 // GetPartial returns the partial identified by name, or an error if
-// the partial could not be found.
-func (g Group) GetPartial(name string) (*Partial, error) {
-	partial, found := g.partials[name]
+// the partial could not be found or the group could not be compiled.
+// name may include PartialPrefix.
+func (g *Group) GetPartial(name string) (*Partial, error) {
+	if err := g.prepare(); err != nil {
+		return nil, err
+	}
+	partial, found := g.lookupPartial(name)
 	if !found {
 		return nil, fmt.Errorf("Could not find partial named %s", name)
 	}
@@ -93,20 +153,57 @@ func (g Group) GetPartial(name string) (*Partial, error) {
 }
 
 // GetLayout returns the layout identified by name, or an error if
-// the layout could not be found.
-func (g Group) GetLayout(name string) (*Layout, error) {
-	layout, found := g.layouts[name]
+// the layout could not be found or the group could not be compiled.
+// name may include LayoutPrefix.
+func (g *Group) GetLayout(name string) (*Layout, error) {
+	if err := g.prepare(); err != nil {
+		return nil, err
+	}
+	layout, found := g.lookupLayout(name)
 	if !found {
 		return nil, fmt.Errorf("Could not find layout named %s", name)
 	}
 	return layout, nil
 }
 
+// lookupPartial returns the partial identified by name. A partial with
+// exactly that name is preferred, so that partials whose names start with
+// PartialPrefix can still be found. Otherwise, PartialPrefix is trimmed
+// from name.
+func (g *Group) lookupPartial(name string) (*Partial, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if partial, found := g.partials[name]; found {
+		return partial, true
+	}
+	partial, found := g.partials[strings.TrimPrefix(name, PartialPrefix)]
+	return partial, found
+}
+
+// lookupLayout returns the layout identified by name. A layout with
+// exactly that name is preferred, so that layouts whose names start with
+// LayoutPrefix can still be found. Otherwise, LayoutPrefix is trimmed
+// from name.
+func (g *Group) lookupLayout(name string) (*Layout, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if layout, found := g.layouts[name]; found {
+		return layout, true
+	}
+	layout, found := g.layouts[strings.TrimPrefix(name, LayoutPrefix)]
+	return layout, found
+}
+
 // MustGetTemplate works like GetTemplate, except that it panics
 // instead of returning an error if the template could not be
 // found.
-func (g Group) MustGetTemplate(name string) *Template {
+func (g *Group) MustGetTemplate(name string) *Template {
+	if err := g.prepare(); err != nil {
+		panic(err)
+	}
+	g.mu.RLock()
 	template, found := g.templates[name]
+	g.mu.RUnlock()
 	if !found {
 		panic("Could not find template named " + name)
 	}
@@ -116,8 +213,11 @@ func (g Group) MustGetTemplate(name string) *Template {
 // MustGetPartial works like GetPartial, except that it panics
 // instead of returning an error if the partial could not be
 // found.
-func (g Group) MustGetPartial(name string) *Partial {
-	partial, found := g.partials[name]
+func (g *Group) MustGetPartial(name string) *Partial {
+	if err := g.prepare(); err != nil {
+		panic(err)
+	}
+	partial, found := g.lookupPartial(name)
 	if !found {
 		panic("Could not find partial named " + name)
 	}
@@ -127,8 +227,11 @@ func (g Group) MustGetPartial(name string) *Partial {
 // MustGetLayout works like GetLayout, except that it panics
 // instead of returning an error if the layout could not be
 // found.
-func (g Group) MustGetLayout(name string) *Layout {
-	layout, found := g.layouts[name]
+func (g *Group) MustGetLayout(name string) *Layout {
+	if err := g.prepare(); err != nil {
+		panic(err)
+	}
+	layout, found := g.lookupLayout(name)
 	if !found {
 		panic("Could not find layout named " + name)
 	}
@@ -138,10 +241,15 @@ func (g Group) MustGetLayout(name string) *Layout {
 // NewGroup creates, initializes, and returns a new Group
 func NewGroup() *Group {
 	return &Group{
-		templates: map[string]*Template{},
-		partials:  map[string]*Partial{},
-		layouts:   map[string]*Layout{},
-		Funcs:     template.FuncMap{},
+		templates:     map[string]*Template{},
+		partials:      map[string]*Partial{},
+		layouts:       map[string]*Layout{},
+		layered:       map[layeredKey]*Template{},
+		templateTrees: map[string]map[string]*parse.Tree{},
+		partialTrees:  map[string]map[string]*parse.Tree{},
+		layoutTrees:   map[string]map[string]*parse.Tree{},
+		stale:         map[string]bool{},
+		Funcs:         template.FuncMap{},
 	}
 }
 
@@ -159,7 +267,10 @@ type Executor interface {
 // about the FuncMap type and how to call functions from inside
 // templates.
 func (g *Group) AddFunc(name string, f interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.Funcs[name] = f
+	g.allStale = true
 }
 
 // PrefixedName returns the name of the partial with PartialsPrefix
@@ -167,11 +278,7 @@ func (g *Group) AddFunc(name string, f interface{}) {
 // can be rendered inside any template, layout, or other partial using
 // the `template` action with the prefixed name as the first argument.
 func (p Partial) PrefixedName() string {
-	if strings.HasPrefix(p.Name(), PartialPrefix) {
-		return p.Name()
-	} else {
-		return PartialPrefix + p.Name()
-	}
+	return prefixedName(PartialPrefix, p.Name())
 }
 
 // PrefixedName returns the name of the layout with LayoutsPrefix
@@ -179,36 +286,88 @@ func (p Partial) PrefixedName() string {
 // can be rendered by any template using the `template` action with
 // the prefixed name as the first argument.
 func (l Layout) PrefixedName() string {
-	if strings.HasPrefix(l.Name(), LayoutPrefix) {
-		return l.Name()
-	} else {
-		return LayoutPrefix + l.Name()
+	return prefixedName(LayoutPrefix, l.Name())
+}
+
+// prefixedName returns name with prefix added to the front, unless
+// name already starts with prefix.
+func prefixedName(prefix, name string) string {
+	if strings.HasPrefix(name, prefix) {
+		return name
 	}
+	return prefix + name
 }
 
 // AddTemplate adds a regular template to the group with the
 // given name and source.
 func (g *Group) AddTemplate(name, src string) error {
-	tmpl, err := template.New(name).Funcs(g.Funcs).Parse(src)
-	if err != nil {
-		return err
+	return g.add(&groupSource{kind: templateKind, name: name, src: src})
+}
+
+// AddTemplateTrees adds a regular template to the group with the
+// given name, using trees instead of parsing some source. trees
+// should contain the tree for the template itself under name, as
+// well as any templates it defines. AddTemplateTrees is used by
+// code generated with the precompile option.
+func (g *Group) AddTemplateTrees(name string, trees map[string]*parse.Tree) error {
+	return g.add(&groupSource{kind: templateKind, name: name, trees: trees})
+}
+
+// parseTrees parses src and returns the trees for the template itself
+// under name, as well as the trees for any templates it defines. The
+// trees are the same in either mode.
+func (g *Group) parseTrees(name, src string) (map[string]*parse.Tree, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return parseSource(name, src, g.Funcs)
+}
+
+// add parses source and adds it to the group as the kind of template
+// indicated by source.kind. If source has a filename, the source is read
+// from the file first. If reloading is enabled, source is also recorded
+// so that it can be added again when reloading.
+func (g *Group) add(source *groupSource) error {
+	if g.reload != nil {
+		g.reload.mu.Lock()
+		defer g.reload.mu.Unlock()
+	}
+	if source.filename != "" {
+		if err := source.read(); err != nil {
+			return err
+		}
+	}
+	trees := source.trees
+	if trees == nil {
+		var err error
+		if trees, err = g.parseTrees(source.name, source.src); err != nil {
+			return err
+		}
+	}
+	if _, found := trees[source.name]; !found {
+		return fmt.Errorf("Could not find parse tree named %s", source.name)
 	}
-	template := Template{
-		Template: tmpl,
+	g.mu.Lock()
+	switch source.kind {
+	case partialKind:
+		g.partialTrees[source.name] = trees
+		g.allStale = true
+	case layoutKind:
+		g.layoutTrees[source.name] = trees
+		g.allStale = true
+	default:
+		g.templateTrees[source.name] = trees
+		g.stale[source.name] = true
 	}
-	g.templates[tmpl.Name()] = &template
-	return g.associateTemplate(template)
+	g.mu.Unlock()
+	g.recordSource(source)
+	return nil
 }
 
 // AddTemplateFile reads the contents of filename and adds
 // a template to the group using the given name and the contents
 // of the file as the source.
 func (g *Group) AddTemplateFile(name, filename string) error {
-	src, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	return g.AddTemplate(name, string(src))
+	return g.add(&groupSource{kind: templateKind, name: name, filename: filename})
 }
 
 // AddTemplateFiles recursively adds all the .tmpl files in dir
@@ -220,51 +379,25 @@ func (g *Group) AddTemplateFiles(dir string) error {
 	return collectTemplateFiles(dir, g.AddTemplateFile)
 }
 
-// associateTemplate adds all the needed associations to the template.
-// Namely, it associates all partials and layouts with the template.
-func (g *Group) associateTemplate(template Template) error {
-	// Associate each partial with this template
-	for _, partial := range g.partials {
-		if template.Lookup(partial.PrefixedName()) == nil {
-			if _, err := template.AddParseTree(partial.PrefixedName(), partial.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	// Associate each layout with this template
-	for _, layout := range g.layouts {
-		if template.Lookup(layout.PrefixedName()) == nil {
-			if _, err := template.AddParseTree(layout.PrefixedName(), layout.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
 // AddPartial adds a partial to the group with the given name
-// and source.
+// and source. Templates, partials, and layouts which were requested
+// before will not see the partial. Request them again instead.
 func (g *Group) AddPartial(name, src string) error {
-	tmpl, err := template.New(name).Funcs(g.Funcs).Parse(src)
-	if err != nil {
-		return err
-	}
-	partial := Partial{
-		Template: tmpl,
-	}
-	g.partials[tmpl.Name()] = &partial
-	return g.associatePartial(partial)
+	return g.add(&groupSource{kind: partialKind, name: name, src: src})
+}
+
+// AddPartialTrees adds a partial to the group with the given name,
+// using trees instead of parsing some source. It works just like
+// AddTemplateTrees.
+func (g *Group) AddPartialTrees(name string, trees map[string]*parse.Tree) error {
+	return g.add(&groupSource{kind: partialKind, name: name, trees: trees})
 }
 
 // AddPartialFile reads the contents of filename and adds
 // a partial to the group using the given name and the contents
 // of the file as the source.
 func (g *Group) AddPartialFile(name, filename string) error {
-	src, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	return g.AddPartial(name, string(src))
+	return g.add(&groupSource{kind: partialKind, name: name, filename: filename})
 }
 
 // AddPartialFiles recursively adds all the .tmpl files in dir
@@ -277,68 +410,25 @@ func (g *Group) AddPartialFiles(dir string) error {
 	return collectTemplateFiles(dir, g.AddPartialFile)
 }
 
-// associatePartial adds all the needed associations to the partial.
-// Namely, it associates it with all templates, layouts, and other
-// partials.
-func (g *Group) associatePartial(partial Partial) error {
-	// Associate this partial with every template
-	for _, template := range g.templates {
-		if template.Lookup(partial.PrefixedName()) == nil {
-			if _, err := template.AddParseTree(partial.PrefixedName(), partial.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	// Associate this partial with every other partial
-	for _, other := range g.partials {
-		if other.Lookup(partial.PrefixedName()) == nil {
-			if _, err := other.AddParseTree(partial.PrefixedName(), partial.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	// Associate every other partial with this partial
-	for _, other := range g.partials {
-		if partial.Lookup(other.PrefixedName()) == nil {
-			if _, err := partial.AddParseTree(other.PrefixedName(), other.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	// Associate this partial with every layout
-	for _, layout := range g.layouts {
-		if layout.Lookup(partial.PrefixedName()) == nil {
-			if _, err := layout.AddParseTree(partial.PrefixedName(), partial.Tree); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
 // AddLayout adds a layout to the group with the given name
-// and source.
+// and source. Templates which were requested before will not see
+// the layout. Request them again instead.
 func (g *Group) AddLayout(name, src string) error {
-	tmpl, err := template.New(name).Funcs(g.Funcs).Parse(src)
-	if err != nil {
-		return err
-	}
-	layout := Layout{
-		Template: tmpl,
-	}
-	g.layouts[tmpl.Name()] = &layout
-	return g.associateLayout(layout)
+	return g.add(&groupSource{kind: layoutKind, name: name, src: src})
+}
+
+// AddLayoutTrees adds a layout to the group with the given name,
+// using trees instead of parsing some source. It works just like
+// AddTemplateTrees.
+func (g *Group) AddLayoutTrees(name string, trees map[string]*parse.Tree) error {
+	return g.add(&groupSource{kind: layoutKind, name: name, trees: trees})
 }
 
 // AddLayoutFile reads the contents of filename and adds
 // a layout to the group using the given name and the contents
 // of the file as the source.
 func (g *Group) AddLayoutFile(name, filename string) error {
-	src, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	return g.AddLayout(name, string(src))
+	return g.add(&groupSource{kind: layoutKind, name: name, filename: filename})
 }
 
 // AddLayoutFiles recursively adds all the .tmpl files in dir
@@ -351,64 +441,192 @@ func (g *Group) AddLayoutFiles(dir string) error {
 	return collectTemplateFiles(dir, g.AddLayoutFile)
 }
 
-// associateLayout adds all the needed associations to the layout.
-// Namely, it associates the layout with all templates and associates
-// all partials with the layout.
-func (g *Group) associateLayout(layout Layout) error {
-	// Associate this layout with every template
-	for _, template := range g.templates {
-		if template.Lookup(layout.PrefixedName()) == nil {
-			if _, err := template.AddParseTree(layout.PrefixedName(), layout.Tree); err != nil {
+// Compile builds the associations between everything that has been added
+// to the group. Every partial is associated with every other partial,
+// every layout, and every regular template. Every layout is associated
+// with every regular template. Since the associations are built all at
+// once, it does not matter in which order templates, partials, and layouts
+// were added. Compile is called automatically by the Get and MustGet
+// methods, so you only need to call it yourself if you want to check for
+// errors before requesting anything. If compiling fails, the group is left
+// as it was.
+func (g *Group) Compile() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.compile()
+}
+
+// prepare reloads the group if needed and then compiles it if anything
+// has been added since it was last compiled.
+func (g *Group) prepare() error {
+	if err := g.reloadIfChanged(); err != nil {
+		return err
+	}
+	g.mu.RLock()
+	compiled := !g.allStale && len(g.stale) == 0
+	g.mu.RUnlock()
+	if compiled {
+		return nil
+	}
+	return g.Compile()
+}
+
+// compile does the work for Compile. If only regular templates have been
+// added since the group was last compiled, only those templates are
+// compiled. Otherwise everything is compiled again. The caller must hold
+// g.mu for writing.
+func (g *Group) compile() error {
+	if !g.allStale && len(g.stale) == 0 {
+		return nil
+	}
+	sharedPartials, sharedLayouts := g.sharedTrees()
+	partials := g.partials
+	layouts := g.layouts
+	stale := g.stale
+	if g.allStale {
+		partials = map[string]*Partial{}
+		for name, trees := range g.partialTrees {
+			html, text, err := g.compileTemplate(name, trees, sharedPartials)
+			if err != nil {
 				return err
 			}
+			partials[name] = &Partial{Template: html, Text: text}
 		}
-	}
-	// Associate each partial with this layout
-	for _, partial := range g.partials {
-		if layout.Lookup(layout.PrefixedName()) == nil {
-			if _, err := layout.AddParseTree(layout.PrefixedName(), partial.Tree); err != nil {
+		layouts = map[string]*Layout{}
+		for name, trees := range g.layoutTrees {
+			trees, err := g.resolveLayout(name, trees, []string{name})
+			if err != nil {
+				return err
+			}
+			html, text, err := g.compileTemplate(name, trees, sharedPartials)
+			if err != nil {
 				return err
 			}
+			layouts[name] = &Layout{Template: html, Text: text}
+		}
+		stale = map[string]bool{}
+		for name := range g.templateTrees {
+			stale[name] = true
+		}
+	}
+	templates := map[string]*Template{}
+	for name := range stale {
+		trees, err := g.resolveLayout(name, g.templateTrees[name], nil)
+		if err != nil {
+			return err
+		}
+		html, text, err := g.compileTemplate(name, trees, sharedPartials, sharedLayouts)
+		if err != nil {
+			return err
 		}
+		templates[name] = &Template{Template: html, Text: text}
 	}
+	if !g.allStale {
+		// Only the stale templates were compiled, so keep the others.
+		for name, template := range g.templates {
+			if _, found := templates[name]; !found {
+				templates[name] = template
+			}
+		}
+	}
+	g.templates = templates
+	g.partials = partials
+	g.layouts = layouts
+	g.layered = map[layeredKey]*Template{}
+	g.stale = map[string]bool{}
+	g.allStale = false
 	return nil
 }
 
+// sharedTrees returns the tree for each partial and layout itself,
+// under its prefixed name. The caller must hold g.mu.
+func (g *Group) sharedTrees() (sharedPartials, sharedLayouts map[string]*parse.Tree) {
+	sharedPartials = map[string]*parse.Tree{}
+	for name, trees := range g.partialTrees {
+		sharedPartials[prefixedName(PartialPrefix, name)] = trees[name]
+	}
+	sharedLayouts = map[string]*parse.Tree{}
+	for name, trees := range g.layoutTrees {
+		sharedLayouts[prefixedName(LayoutPrefix, name)] = trees[name]
+	}
+	return sharedPartials, sharedLayouts
+}
+
+// compileTemplate creates a new template with the given name and the
+// group's FuncMap out of trees, which holds the trees for the template
+// itself and any templates it defines. Then it associates the template
+// with each tree in shared, unless the template already defines a
+// template with the same name. Every tree is copied, so the template
+// does not share any trees with other templates. If the group uses
+// TextMode, the template is returned as text, otherwise as html. The
+// caller must hold g.mu.
+func (g *Group) compileTemplate(name string, trees map[string]*parse.Tree, shared ...map[string]*parse.Tree) (html *template.Template, text *texttemplate.Template, err error) {
+	// addParseTree is the AddParseTree method of whichever template is
+	// being created.
+	var addParseTree func(name string, tree *parse.Tree) error
+	if g.mode == TextMode {
+		text = texttemplate.New(name).Funcs(texttemplate.FuncMap(g.Funcs))
+		addParseTree = func(name string, tree *parse.Tree) error {
+			_, err := text.AddParseTree(name, tree)
+			return err
+		}
+	} else {
+		html = template.New(name).Funcs(g.Funcs)
+		addParseTree = func(name string, tree *parse.Tree) error {
+			_, err := html.AddParseTree(name, tree)
+			return err
+		}
+	}
+	for treeName, tree := range trees {
+		if err := addParseTree(treeName, tree.Copy()); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, sharedTrees := range shared {
+		for treeName, tree := range sharedTrees {
+			if _, found := trees[treeName]; found {
+				continue
+			}
+			if err := addParseTree(treeName, tree.Copy()); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	// AddParseTree replaces the template for name with a new one, so
+	// the template that was created first does not have a tree.
+	if text != nil {
+		return nil, text.Lookup(name), nil
+	}
+	return html.Lookup(name), nil, nil
+}
+
 // AddAllFiles adds the .tmpl files located in templatesDir, partialsDir,
 // and layoutsDir to the group as regular templates, partials, and layouts,
 // respectively. It also adds the needed associations. The name assigned to
 // each template, partial, or layout is based on the filename and the path
 // relative to dir, just as it is in AddTemplateFiles, AddPartialFiles, and
-// AddLayoutFiles, respectively.
+// AddLayoutFiles, respectively. partialsDir and layoutsDir may be empty
+// strings, in which case no partials or layouts are added.
 func (g *Group) AddAllFiles(templatesDir, partialsDir, layoutsDir string) error {
-	for dir, f := range map[string]func(string) error{
-		templatesDir: g.AddTemplateFiles,
-		partialsDir:  g.AddPartialFiles,
-		layoutsDir:   g.AddLayoutFiles,
-	} {
-		if err := f(dir); err != nil {
+	if partialsDir != "" {
+		if err := g.AddPartialFiles(partialsDir); err != nil {
 			return err
 		}
 	}
-	return nil
+	if layoutsDir != "" {
+		if err := g.AddLayoutFiles(layoutsDir); err != nil {
+			return err
+		}
+	}
+	return g.AddTemplateFiles(templatesDir)
 }
 
 // collectTemplateFiles is a function which navigates recursively through
-// dir and its subdirectories and finds any files with the .tmpl file extension.
-// Then it calls the given handler func with the filename and template name (without
-// the PartialsPrefix or LayoutsPrefix added).
+// dir and its subdirectories and finds any files with one of the
+// DefaultExtensions, skipping any which are ignored by the IgnoreFile in
+// dir. Then it calls the given handler func with the filename and template
+// name (without the PartialsPrefix or LayoutsPrefix added), in order of
+// the names. Names always use forward slashes.
 func collectTemplateFiles(dir string, handler func(name, filename string) error) error {
-	dir = filepath.Clean(dir)
-	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if strings.HasSuffix(path, ".tmpl") {
-			name := strings.TrimSuffix(strings.TrimPrefix(path, dir+string(os.PathSeparator)), ".tmpl")
-			if err := handler(name, path); err != nil {
-				return err
-			}
-		}
-		return nil
-	}); err != nil {
-		return err
-	}
-	return nil
+	return newFileMatcher(nil, nil).collect(dir, handler)
 }
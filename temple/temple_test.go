@@ -6,7 +6,11 @@
 package temple
 
 import (
+	"fmt"
+	"io/ioutil"
+	"sync"
 	"testing"
+	"text/template/parse"
 )
 
 func TestAddTemplate(t *testing.T) {
@@ -15,13 +19,33 @@ func TestAddTemplate(t *testing.T) {
 		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
 	}
 	// Get the template from the map
-	testTmpl, found := g.templates["test"]
-	if !found {
-		t.Fatal(`Template named "test" was not added to map of Templates`)
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
 	}
 	expectExecutorOutputs(t, testTmpl, "world", "Hello, world!")
 }
 
+func TestAddTemplateTrees(t *testing.T) {
+	g := NewGroup()
+	trees, err := parse.Parse("test", `{{ define "greeting" }}Hello{{ end }}{{ template "greeting" }}, {{ . }}!`, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddTemplateTrees("test", trees); err != nil {
+		t.Fatalf("Unexpected error in AddTemplateTrees: %s", err.Error())
+	}
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, testTmpl, "world", "Hello, world!")
+	// There must be a tree for the template itself
+	if err := g.AddTemplateTrees("missing", trees); err == nil {
+		t.Error("Expected an error in AddTemplateTrees when there is no tree for the given name but got none")
+	}
+}
+
 func TestAddPartial(t *testing.T) {
 	g := NewGroup()
 	// Add some Partials and make sure each is added to the map
@@ -37,8 +61,14 @@ func TestAddPartial(t *testing.T) {
 		if err := g.AddPartial(name, src); err != nil {
 			t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
 		}
-		if _, found := g.partials[name]; !found {
-			t.Errorf(`Partial named "%s" was not added to the map of Partials`, name)
+	}
+	for name := range partials {
+		if _, err := g.GetPartial(name); err != nil {
+			t.Errorf("Unexpected error in GetPartial: %s", err.Error())
+		}
+		// The partial can also be found by its prefixed name.
+		if _, err := g.GetPartial("partials/" + name); err != nil {
+			t.Errorf("Unexpected error in GetPartial: %s", err.Error())
 		}
 	}
 	// The test template calls on each of the four partials. This tests that
@@ -46,13 +76,48 @@ func TestAddPartial(t *testing.T) {
 	if err := g.AddTemplate("test", `{{ template "partials/foo" }} {{ template "partials/bar" }} {{ template "partials/baz" }} {{ template "partials/foobarbaz" }}`); err != nil {
 		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
 	}
-	testTmpl, found := g.templates["test"]
-	if !found {
-		t.Fatal(`Template named "test" was not added to map of Templates`)
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
 	}
 	expectExecutorOutputs(t, testTmpl, nil, "foo bar baz foobarbaz")
 }
 
+func TestGetPrefixedNames(t *testing.T) {
+	// Names which already start with the prefix must still be found by
+	// the name they were added with.
+	g := NewGroup()
+	if err := g.AddPartial("partials/foo", "foo"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	if err := g.AddLayout("layouts/app", "app"); err != nil {
+		t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
+	}
+	partial, err := g.GetPartial("partials/foo")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetPartial: %s", err.Error())
+	}
+	expectExecutorOutputs(t, partial, nil, "foo")
+	if g.MustGetPartial("partials/foo") != partial {
+		t.Error(`Expected MustGetPartial("partials/foo") to return the partial named "partials/foo"`)
+	}
+	layout, err := g.GetLayout("layouts/app")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetLayout: %s", err.Error())
+	}
+	expectExecutorOutputs(t, layout, nil, "app")
+	if g.MustGetLayout("layouts/app") != layout {
+		t.Error(`Expected MustGetLayout("layouts/app") to return the layout named "layouts/app"`)
+	}
+	// A partial with exactly the given name is preferred over one which
+	// matches after the prefix is trimmed.
+	if err := g.AddPartial("foo", "bar"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	expectExecutorOutputs(t, g.MustGetPartial("partials/foo"), nil, "foo")
+	expectExecutorOutputs(t, g.MustGetPartial("foo"), nil, "bar")
+}
+
 func TestAddLayout(t *testing.T) {
 	g := NewGroup()
 	// The foo partial will be called on by the header layout, which tests that
@@ -60,27 +125,136 @@ func TestAddLayout(t *testing.T) {
 	if err := g.AddPartial("foo", "foo"); err != nil {
 		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
 	}
-	if _, found := g.partials["foo"]; !found {
-		t.Errorf(`Partial named "%s" was not added to the map of Partials`, "foo")
-	}
 	// The header layout renders a content template (which must be defined by a template using
 	// the layout) and calls for the foo partial.
 	if err := g.AddLayout("header", `<h2>{{ template "content" }} {{ template "partials/foo" }}</h2>`); err != nil {
 		t.Fatalf("Unexpected error in AddLayout: %s", err.Error())
 	}
-	if _, found := g.layouts["header"]; !found {
-		t.Errorf(`Layout named "%s" was not added to the map of Layouts`, "header")
-	}
 	// The test template defines a content template and attempts to render itself inside the
 	// header layout.
 	if err := g.AddTemplate("test", `{{ define "content"}}test{{end}}{{ template "layouts/header" }}`); err != nil {
 		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
 	}
-	testTmpl, found := g.templates["test"]
-	if !found {
-		t.Fatal(`Template named "test" was not added to map of Templates`)
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
 	}
 	expectExecutorOutputs(t, testTmpl, nil, "<h2>test foo</h2>")
+	if _, err := g.GetPartial("foo"); err != nil {
+		t.Errorf("Unexpected error in GetPartial: %s", err.Error())
+	}
+	// The partial should also be associated with the layout itself.
+	headerLayout, err := g.GetLayout("header")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetLayout: %s", err.Error())
+	}
+	if g.MustGetLayout("layouts/header") != headerLayout {
+		t.Error(`Expected MustGetLayout("layouts/header") to return the layout named "header"`)
+	}
+	if headerLayout.Lookup("partials/foo") == nil {
+		t.Error(`Expected partial named "foo" to be associated with layout named "header"`)
+	}
+}
+
+func TestAddInAnyOrder(t *testing.T) {
+	// Each item calls on the ones before it, so none of them can be
+	// rendered unless everything is associated correctly.
+	type item struct {
+		kind sourceKind
+		name string
+		src  string
+	}
+	items := []item{
+		{kind: partialKind, name: "foo", src: "foo"},
+		{kind: partialKind, name: "bar", src: `{{ template "partials/foo" }}bar`},
+		{kind: layoutKind, name: "header", src: `<h2>{{ template "content" }} {{ template "partials/bar" }}</h2>`},
+		{kind: templateKind, name: "test", src: `{{ define "content" }}test{{ end }}{{ template "layouts/header" }}`},
+	}
+	for _, order := range permutations(len(items)) {
+		g := NewGroup()
+		for _, i := range order {
+			var err error
+			switch items[i].kind {
+			case partialKind:
+				err = g.AddPartial(items[i].name, items[i].src)
+			case layoutKind:
+				err = g.AddLayout(items[i].name, items[i].src)
+			default:
+				err = g.AddTemplate(items[i].name, items[i].src)
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error adding %s: %s", items[i].name, err.Error())
+			}
+		}
+		if err := g.Compile(); err != nil {
+			t.Fatalf("Unexpected error in Compile for order %v: %s", order, err.Error())
+		}
+		testTmpl, err := g.GetTemplate("test")
+		if err != nil {
+			t.Fatalf("Unexpected error in GetTemplate for order %v: %s", order, err.Error())
+		}
+		expectExecutorOutputs(t, testTmpl, nil, "<h2>test foobar</h2>")
+		barPartial, err := g.GetPartial("bar")
+		if err != nil {
+			t.Fatalf("Unexpected error in GetPartial for order %v: %s", order, err.Error())
+		}
+		expectExecutorOutputs(t, barPartial, nil, "foobar")
+	}
+}
+
+func TestAddAfterGet(t *testing.T) {
+	g := NewGroup()
+	if err := g.AddTemplate("test", `{{ template "partials/foo" }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	// The partial has not been added yet, so executing should fail.
+	testTmpl, err := g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	if err := testTmpl.Execute(ioutil.Discard, nil); err == nil {
+		t.Error("Expected an error executing a template which calls a missing partial but got none")
+	}
+	if err := g.AddPartial("foo", "foo"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	// The template which was requested before is a snapshot, so it does not
+	// see the new partial.
+	if err := testTmpl.Execute(ioutil.Discard, nil); err == nil {
+		t.Error("Expected a template requested before AddPartial to not see the new partial")
+	}
+	// Getting the template again should return one which is associated
+	// with the new partial.
+	testTmpl, err = g.GetTemplate("test")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
+	}
+	expectExecutorOutputs(t, testTmpl, nil, "foo")
+	// The same goes for changing a partial.
+	if err := g.AddPartial("foo", "bar"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	expectExecutorOutputs(t, testTmpl, nil, "foo")
+	expectExecutorOutputs(t, g.MustGetTemplate("test"), nil, "bar")
+}
+
+// permutations returns every ordering of the integers from 0 to n-1.
+func permutations(n int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+	result := [][]int{}
+	for _, perm := range permutations(n - 1) {
+		// Insert n-1 at every position in each permutation of the rest.
+		for i := 0; i <= len(perm); i++ {
+			order := make([]int, 0, n)
+			order = append(order, perm[:i]...)
+			order = append(order, n-1)
+			order = append(order, perm[i:]...)
+			result = append(result, order)
+		}
+	}
+	return result
 }
 
 func TestAddAllFiles(t *testing.T) {
@@ -98,9 +272,53 @@ func TestAddAllFiles(t *testing.T) {
 		{Title: "Two"},
 		{Title: "Three"},
 	}
-	todosTmpl, found := g.templates["todos/index"]
-	if !found {
-		t.Fatal(`Template named "todos/index" was not added to map of Templates`)
+	todosTmpl, err := g.GetTemplate("todos/index")
+	if err != nil {
+		t.Fatalf("Unexpected error in GetTemplate: %s", err.Error())
 	}
 	expectExecutorOutputs(t, todosTmpl, todos, "<html><head><title>Todos</title></head><body><ul><li>One</li><li>Two</li><li>Three</li></ul></body></html>")
 }
+
+func TestConcurrentAddAndExecute(t *testing.T) {
+	g := NewGroup()
+	if err := g.AddPartial("foo", "foo"); err != nil {
+		t.Fatalf("Unexpected error in AddPartial: %s", err.Error())
+	}
+	if err := g.AddTemplate("test", `{{ template "partials/foo" }} {{ . }}`); err != nil {
+		t.Fatalf("Unexpected error in AddTemplate: %s", err.Error())
+	}
+	// Add new templates in some goroutines while getting and executing
+	// templates in others. Run with -race to detect data races.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			g.AddFunc(fmt.Sprintf("f%d", i), func() int { return i })
+			name := fmt.Sprintf("test%d", i)
+			if err := g.AddTemplate(name, fmt.Sprintf(`{{ template "partials/foo" }} {{ f%d }}`, i)); err != nil {
+				t.Errorf("Unexpected error in AddTemplate: %s", err.Error())
+				return
+			}
+			expectExecutorOutputs(t, g.MustGetTemplate(name), nil, fmt.Sprintf("foo %d", i))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			testTmpl, err := g.GetTemplate("test")
+			if err != nil {
+				t.Errorf("Unexpected error in GetTemplate: %s", err.Error())
+				return
+			}
+			expectExecutorOutputs(t, testTmpl, i, fmt.Sprintf("foo %d", i))
+			if _, err := g.GetPartial("foo"); err != nil {
+				t.Errorf("Unexpected error in GetPartial: %s", err.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		if _, err := g.GetTemplate(fmt.Sprintf("test%d", i)); err != nil {
+			t.Errorf("Unexpected error in GetTemplate: %s", err.Error())
+		}
+	}
+}
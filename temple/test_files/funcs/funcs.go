@@ -0,0 +1,19 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// Package funcs declares template functions which are installed
+// in generated code with the --funcs flag in the build tests.
+package funcs
+
+import (
+	"fmt"
+	"html/template"
+)
+
+var FuncMap = template.FuncMap{
+	"greet": func(name string) string {
+		return fmt.Sprintf("Hello, %s!", name)
+	},
+}
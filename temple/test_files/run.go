@@ -24,10 +24,18 @@ var todos = []Todo{
 }
 
 func main() {
-	todosTmpl, err := GetTemplate("todos/index")
+	todosTmpl, err := GetTemplate(TemplateTodosIndex)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// The names of partials and layouts include their prefix, and can be
+	// passed to GetPartial and GetLayout.
+	if _, err := GetPartial(PartialTodo); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := GetLayout(LayoutApp); err != nil {
+		log.Fatal(err)
+	}
 	if err := todosTmpl.Execute(os.Stdout, todos); err != nil {
 		log.Fatal(err)
 	}
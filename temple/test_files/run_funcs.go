@@ -0,0 +1,25 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// NOTE: this file is meant to be run together with a generated file created
+// by Build with the WithFuncs option. By itself it won't compile because
+// GetTemplate is not defined.
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	greetTmpl, err := GetTemplate("greet")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := greetTmpl.Execute(os.Stdout, "world"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// NOTE: this file is meant to be run together with a generated file created
+// by Build with the WithPrecompile option. By itself it won't compile because
+// GetTemplate is not defined.
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+type Nested struct {
+	Name string
+}
+
+type Kitchen struct {
+	Title   string
+	Show    bool
+	Items   []string
+	Empty   []string
+	Missing interface{}
+	Nested  Nested
+	Map     map[string]string
+}
+
+var kitchen = Kitchen{
+	Title:  "Kitchen",
+	Show:   true,
+	Items:  []string{"a", "b", "c", "d"},
+	Nested: Nested{Name: "nested"},
+	Map:    map[string]string{"key": "value"},
+}
+
+func main() {
+	kitchenTmpl, err := GetTemplate("kitchen")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := kitchenTmpl.Execute(os.Stdout, kitchen); err != nil {
+		log.Fatal(err)
+	}
+}
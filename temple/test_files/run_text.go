@@ -0,0 +1,38 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// NOTE: this file is meant to be run together with a generated file created
+// by Build with the text mode. By itself it won't compile because
+// GetTemplate is not defined.
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+type Email struct {
+	Name    string
+	Message string
+	From    string
+	Email   string
+}
+
+func main() {
+	emailTmpl, err := GetTemplate("email")
+	if err != nil {
+		log.Fatal(err)
+	}
+	email := Email{
+		Name:    "Bob & Alice",
+		Message: "Your order of <widgets> has shipped.",
+		From:    "Temple",
+		Email:   "temple@example.com",
+	}
+	if err := emailTmpl.Execute(os.Stdout, email); err != nil {
+		log.Fatal(err)
+	}
+}
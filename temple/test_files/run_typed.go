@@ -0,0 +1,31 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// NOTE: this file is meant to be run together with a generated file created
+// by Build from the typed_templates directory. By itself it won't compile
+// because RenderTodosIndex is not defined.
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/go-humble/temple/temple/test_files/typed/models"
+)
+
+func main() {
+	list := models.TodoList{
+		Name: "Chores",
+		Todos: []*models.Todo{
+			{Title: "dishes", Done: true},
+			{Title: "laundry", Tags: map[string]string{"color": "blue"}},
+		},
+		Owner: &models.User{Name: "Bob"},
+	}
+	if err := RenderTodosIndex(os.Stdout, list); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+// Package models holds the data types used by the templates in
+// test_files/typed_templates and test_files/typed_partials.
+package models
+
+import "strings"
+
+type Todo struct {
+	Title string
+	Done  bool
+	Tags  map[string]string
+}
+
+// Shout returns the title of the todo in upper case.
+func (t Todo) Shout() string {
+	return strings.ToUpper(t.Title)
+}
+
+type TodoList struct {
+	Name  string
+	Todos []*Todo
+	Owner *User
+}
+
+type User struct {
+	Name string
+}
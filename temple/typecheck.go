@@ -0,0 +1,445 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"errors"
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// dataDirective is the key for the directive which declares the type of
+// the data a template, partial, or layout expects, e.g.
+// {{/* temple:data github.com/me/app/models.Todo */}}.
+const dataDirective = "data"
+
+// typeLoader loads the types named by data directives.
+type typeLoader struct {
+	importer types.Importer
+	types    map[string]types.Type
+}
+
+// newTypeLoader creates and returns a typeLoader which type checks the
+// packages it needs from source.
+func newTypeLoader() *typeLoader {
+	return &typeLoader{
+		importer: importer.ForCompiler(token.NewFileSet(), "source", nil),
+		types:    map[string]types.Type{},
+	}
+}
+
+// load returns the type described by spec, which must be either the name
+// of a predeclared type such as "string" or have the form
+// "import/path.TypeName". Either form may be preceded by any number of
+// "*" and "[]" to describe pointers and slices, e.g.
+// "[]*github.com/me/app/models.Todo".
+func (l *typeLoader) load(spec string) (types.Type, error) {
+	if typ, found := l.types[spec]; found {
+		return typ, nil
+	}
+	var typ types.Type
+	switch {
+	case strings.HasPrefix(spec, "*"):
+		elem, err := l.load(spec[1:])
+		if err != nil {
+			return nil, err
+		}
+		typ = types.NewPointer(elem)
+	case strings.HasPrefix(spec, "[]"):
+		elem, err := l.load(spec[2:])
+		if err != nil {
+			return nil, err
+		}
+		typ = types.NewSlice(elem)
+	default:
+		i := strings.LastIndex(spec, ".")
+		if i == -1 {
+			obj, ok := types.Universe.Lookup(spec).(*types.TypeName)
+			if !ok {
+				return nil, fmt.Errorf("temple: could not find data type %s", spec)
+			}
+			typ = obj.Type()
+			break
+		}
+		pkg, err := l.importer.Import(spec[:i])
+		if err != nil {
+			return nil, fmt.Errorf("temple: could not load package for data type %s: %s", spec, err.Error())
+		}
+		obj, ok := pkg.Scope().Lookup(spec[i+1:]).(*types.TypeName)
+		if !ok || !obj.Exported() {
+			return nil, fmt.Errorf("temple: could not find data type %s", spec)
+		}
+		typ = obj.Type()
+	}
+	l.types[spec] = typ
+	return typ, nil
+}
+
+// checkTemplateData checks each template, partial, and layout in g which
+// declares its data type with a data directive. Any fields, methods, and
+// map keys it accesses must exist, anything it ranges over must be
+// iterable, and any template it calls with some data is checked using the
+// type of that data. g must already be compiled, and its trees should have
+// been added with their ParseName set to the name of the file they came
+// from, so that errors can be reported with the filename and line number.
+// If any errors are found, they are all returned together. Data types are
+// loaded with loader.
+func checkTemplateData(g *Group, loader *typeLoader) error {
+	c := &dataChecker{
+		loader: loader,
+		errs:   map[string]bool{},
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for name, template := range g.templates {
+		c.checkEntry(g.templateTrees[name][name], name, template.Template, template.Text)
+	}
+	for name, partial := range g.partials {
+		c.checkEntry(g.partialTrees[name][name], name, partial.Template, partial.Text)
+	}
+	for name, layout := range g.layouts {
+		c.checkEntry(g.layoutTrees[name][name], name, layout.Template, layout.Text)
+	}
+	if len(c.errs) == 0 {
+		return nil
+	}
+	msgs := []string{}
+	for msg := range c.errs {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// dataChecker walks through parse trees and checks that they can be
+// executed with data of a certain type. Any type which is nil is unknown,
+// e.g. because it is the result of a function, and is not checked.
+type dataChecker struct {
+	loader *typeLoader
+	// set holds every template that can be called with the `template`
+	// action from the tree being checked, by name.
+	set map[string]*parse.Tree
+	// tree is the tree being checked, and vars holds the variables which
+	// are in scope.
+	tree *parse.Tree
+	vars []variable
+	// checked holds the trees which have already been checked with a
+	// certain type, so that recursive templates are only checked once.
+	checked map[string]bool
+	errs    map[string]bool
+}
+
+// variable is a template variable, such as $ or $todo, and its type.
+type variable struct {
+	name string
+	typ  types.Type
+}
+
+// checkEntry checks the template identified by name if raw, which is the
+// tree it was added with, has a data directive. html or text is the
+// compiled template.
+func (c *dataChecker) checkEntry(raw *parse.Tree, name string, html *template.Template, text *texttemplate.Template) {
+	spec := findDirective(raw, dataDirective)
+	if spec == "" {
+		return
+	}
+	// Each template has its own set of associated templates, so anything
+	// checked for another template needs to be checked again.
+	c.checked = map[string]bool{}
+	c.set = associatedTrees(html, text)
+	tree := c.set[name]
+	typ, err := c.loader.load(spec)
+	if err != nil {
+		c.tree = tree
+		c.errorf(tree.Root, "%s", err.Error())
+		return
+	}
+	c.checkTree(tree, typ)
+}
+
+// checkTree checks that tree can be executed with data of type typ.
+func (c *dataChecker) checkTree(tree *parse.Tree, typ types.Type) {
+	key := tree.ParseName + "\x00" + tree.Name + "\x00" + typeString(typ)
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+	prevTree, prevVars := c.tree, c.vars
+	c.tree = tree
+	c.vars = []variable{{name: "$", typ: typ}}
+	c.walk(tree.Root, typ)
+	c.tree, c.vars = prevTree, prevVars
+}
+
+// errorf records an error at the location of node.
+func (c *dataChecker) errorf(node parse.Node, format string, args ...interface{}) {
+	c.errs[errorLocation(c.tree, node)+": "+fmt.Sprintf(format, args...)] = true
+}
+
+// walk checks node, where dot has type dot.
+func (c *dataChecker) walk(node parse.Node, dot types.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		mark := len(c.vars)
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.WithNode:
+		mark := len(c.vars)
+		c.walk(n.List, c.pipe(n.Pipe, dot))
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.RangeNode:
+		c.rangeNode(n, dot)
+	case *parse.TemplateNode:
+		c.templateNode(n, dot)
+	}
+}
+
+// rangeNode checks a range action, where dot has type dot.
+func (c *dataChecker) rangeNode(n *parse.RangeNode, dot types.Type) {
+	mark := len(c.vars)
+	typ := c.commands(n.Pipe, dot)
+	var key, elem types.Type
+	if typ != nil {
+		switch u := typ.Underlying().(type) {
+		case *types.Slice:
+			key, elem = types.Typ[types.Int], u.Elem()
+		case *types.Array:
+			key, elem = types.Typ[types.Int], u.Elem()
+		case *types.Pointer:
+			if array, ok := u.Elem().Underlying().(*types.Array); ok {
+				key, elem = types.Typ[types.Int], array.Elem()
+			} else {
+				c.errorf(n, "range can't iterate over %s", typeString(typ))
+			}
+		case *types.Map:
+			key, elem = u.Key(), u.Elem()
+		case *types.Chan:
+			elem = u.Elem()
+		case *types.Basic:
+			if u.Info()&types.IsInteger != 0 {
+				key, elem = typ, typ
+			} else if u.Kind() != types.UntypedNil {
+				c.errorf(n, "range can't iterate over %s", typeString(typ))
+			}
+		case *types.Interface, *types.Signature:
+			// The value might be iterable at runtime.
+		default:
+			c.errorf(n, "range can't iterate over %s", typeString(typ))
+		}
+	}
+	switch len(n.Pipe.Decl) {
+	case 1:
+		c.declare(n.Pipe.Decl[0], elem)
+	case 2:
+		c.declare(n.Pipe.Decl[0], key)
+		c.declare(n.Pipe.Decl[1], elem)
+	}
+	c.walk(n.List, elem)
+	c.walk(n.ElseList, dot)
+	c.vars = c.vars[:mark]
+}
+
+// templateNode checks a template action, where dot has type dot. If the
+// template it calls declares its own data type, the type of the data it
+// is called with must be assignable to it. Otherwise the template is
+// checked with the type of the data it is called with.
+func (c *dataChecker) templateNode(n *parse.TemplateNode, dot types.Type) {
+	var typ types.Type
+	if n.Pipe != nil {
+		typ = c.pipe(n.Pipe, dot)
+	}
+	tree, found := c.set[n.Name]
+	if !found || tree == nil {
+		return
+	}
+	if spec := findDirective(tree, dataDirective); spec != "" {
+		declared, err := c.loader.load(spec)
+		if err != nil {
+			c.errorf(n, "%s", err.Error())
+			return
+		}
+		if typ != nil && !types.AssignableTo(typ, declared) {
+			c.errorf(n, "template %s expects data of type %s but got %s", n.Name, typeString(declared), typeString(typ))
+		}
+		return
+	}
+	if typ != nil {
+		c.checkTree(tree, typ)
+	}
+}
+
+// declare adds a new variable to the current scope.
+func (c *dataChecker) declare(v *parse.VariableNode, typ types.Type) {
+	c.vars = append(c.vars, variable{name: v.Ident[0], typ: typ})
+}
+
+// pipe checks pipe and returns the type of its result. Any variables it
+// declares are added to the current scope.
+func (c *dataChecker) pipe(pipe *parse.PipeNode, dot types.Type) types.Type {
+	typ := c.commands(pipe, dot)
+	if !pipe.IsAssign {
+		for _, v := range pipe.Decl {
+			c.declare(v, typ)
+		}
+	}
+	return typ
+}
+
+// commands checks the commands in pipe and returns the type of the result
+// of the last one.
+func (c *dataChecker) commands(pipe *parse.PipeNode, dot types.Type) types.Type {
+	if pipe == nil {
+		return nil
+	}
+	var typ types.Type
+	for _, cmd := range pipe.Cmds {
+		typ = c.command(cmd, dot)
+	}
+	return typ
+}
+
+// command checks cmd and returns the type of its result.
+func (c *dataChecker) command(cmd *parse.CommandNode, dot types.Type) types.Type {
+	for _, arg := range cmd.Args[1:] {
+		c.arg(arg, dot)
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		return builtinResultType(ident.Ident)
+	}
+	return c.arg(cmd.Args[0], dot)
+}
+
+// arg checks node and returns its type.
+func (c *dataChecker) arg(node parse.Node, dot types.Type) types.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fields(n, dot, n.Ident)
+	case *parse.ChainNode:
+		return c.fields(n, c.arg(n.Node, dot), n.Field)
+	case *parse.VariableNode:
+		return c.fields(n, c.variable(n.Ident[0]), n.Ident[1:])
+	case *parse.PipeNode:
+		return c.pipe(n, dot)
+	case *parse.StringNode:
+		return types.Typ[types.String]
+	case *parse.BoolNode:
+		return types.Typ[types.Bool]
+	case *parse.NumberNode:
+		switch {
+		case n.IsInt:
+			return types.Typ[types.Int]
+		case n.IsFloat:
+			return types.Typ[types.Float64]
+		}
+	}
+	return nil
+}
+
+// variable returns the type of the variable with the given name which is
+// in scope.
+func (c *dataChecker) variable(name string) types.Type {
+	for i := len(c.vars) - 1; i >= 0; i-- {
+		if c.vars[i].name == name {
+			return c.vars[i].typ
+		}
+	}
+	return nil
+}
+
+// fields returns the type of the result of accessing each of names in turn,
+// starting with typ.
+func (c *dataChecker) fields(node parse.Node, typ types.Type, names []string) types.Type {
+	for _, name := range names {
+		if typ = c.field(node, typ, name); typ == nil {
+			return nil
+		}
+	}
+	return typ
+}
+
+// field returns the type of the result of accessing the field, method, or
+// map key with the given name on a value of type typ. It follows the same
+// rules as text/template: methods are found first, then struct fields and
+// map keys, and pointers are dereferenced.
+func (c *dataChecker) field(node parse.Node, typ types.Type, name string) types.Type {
+	if typ == nil {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name)
+	if method, ok := obj.(*types.Func); ok && method.Exported() {
+		results := method.Type().(*types.Signature).Results()
+		if results.Len() == 0 {
+			return nil
+		}
+		return results.At(0).Type()
+	}
+	under := typ.Underlying()
+	if pointer, ok := under.(*types.Pointer); ok {
+		under = pointer.Elem().Underlying()
+	}
+	switch u := under.(type) {
+	case *types.Struct:
+		if field, ok := obj.(*types.Var); ok && field.Exported() {
+			return field.Type()
+		}
+	case *types.Map:
+		if key, ok := u.Key().Underlying().(*types.Basic); ok && key.Info()&types.IsString != 0 {
+			return u.Elem()
+		}
+	case *types.Interface:
+		// The dynamic value might have the field.
+		return nil
+	}
+	c.errorf(node, "can't evaluate field %s in type %s", name, typeString(typ))
+	return nil
+}
+
+// builtinResultType returns the type of the result of the builtin function
+// with the given name, or nil if it is not known. The result types of other
+// functions are never known.
+func builtinResultType(name string) types.Type {
+	switch name {
+	case "len":
+		return types.Typ[types.Int]
+	case "not", "eq", "ne", "lt", "le", "gt", "ge":
+		return types.Typ[types.Bool]
+	case "html", "js", "print", "printf", "println", "urlquery":
+		return types.Typ[types.String]
+	}
+	return nil
+}
+
+// typeString returns typ as a string, using package names instead of
+// import paths.
+func typeString(typ types.Type) string {
+	if typ == nil {
+		return "<unknown>"
+	}
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template/parse"
+)
+
+// testModels is type checked to get the types used by the data type tests.
+const testModels = `package models
+
+type Todo struct {
+	Title string
+	Done  bool
+	Tags  map[string]string
+	notes string
+}
+
+func (t Todo) Shout() string { return t.Title }
+
+func (t *Todo) Check() (bool, error) { return t.Done, nil }
+
+type TodoList struct {
+	Name  string
+	Todos []*Todo
+	Owner *User
+	Meta  interface{}
+}
+
+type User struct {
+	Name string
+}
+`
+
+// newTestTypeLoader returns a typeLoader which has already loaded the types
+// in testModels as "models.Todo", "models.TodoList", and "models.User".
+func newTestTypeLoader(t *testing.T) *typeLoader {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "models.go", testModels, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := (&types.Config{}).Check("models", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := newTypeLoader()
+	for _, name := range []string{"Todo", "TodoList", "User"} {
+		loader.types["models."+name] = pkg.Scope().Lookup(name).Type()
+	}
+	return loader
+}
+
+func TestCheckTemplateData(t *testing.T) {
+	testCases := []struct {
+		src      string
+		partial  string
+		expected []string
+	}{
+		{
+			// Fields, methods, map keys, variables, range, and with all work.
+			src:     `{{ .Name }}{{ with .Owner }}{{ .Name }}{{ end }}{{ range $i, $todo := .Todos }}{{ $i }}{{ $todo.Title }}{{ .Shout }}{{ .Check }}{{ .Tags.color }}{{ $.Name }}{{ end }}{{ .Meta.Anything }}`,
+			partial: `{{ .Title }}`,
+		},
+		{
+			// Templates called with some data are checked using its type.
+			src:      `{{ range .Todos }}{{ template "partials/test" . }}{{ end }}`,
+			partial:  "{{ .Title }}\n{{ .Titel }}",
+			expected: []string{`partials/test.tmpl:2: can't evaluate field Titel in type *models.Todo`},
+		},
+		{
+			src: "{{ .Nmae }}\n{{ range .Name }}{{ end }}\n{{ range .Todos }}{{ .notes }}{{ end }}\n{{ $x := .Owner }}{{ $x.Email }}",
+			expected: []string{
+				`templates/test.tmpl:1: can't evaluate field Nmae in type models.TodoList`,
+				`templates/test.tmpl:2: range can't iterate over string`,
+				`templates/test.tmpl:3: can't evaluate field notes in type *models.Todo`,
+				`templates/test.tmpl:4: can't evaluate field Email in type *models.User`,
+			},
+		},
+		{
+			// Templates which declare their own data type are checked
+			// separately.
+			src:     `{{ template "partials/test" .Owner }}`,
+			partial: `{{/* temple:data models.Todo */}}{{ .Name }}`,
+			expected: []string{
+				`partials/test.tmpl:1: can't evaluate field Name in type models.Todo`,
+				`templates/test.tmpl:1: template partials/test expects data of type models.Todo but got *models.User`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		g := NewGroup()
+		add := func(name, filename, src string, addTrees func(string, map[string]*parse.Tree) error) {
+			trees, err := parseSource(name, src, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tree := range trees {
+				tree.ParseName = filename
+			}
+			if err := addTrees(name, trees); err != nil {
+				t.Fatal(err)
+			}
+		}
+		add("test", "templates/test.tmpl", `{{/* temple:data models.TodoList */}}`+tc.src, g.AddTemplateTrees)
+		add("test", "partials/test.tmpl", tc.partial, g.AddPartialTrees)
+		if err := g.Compile(); err != nil {
+			t.Fatal(err)
+		}
+		err := checkTemplateData(g, newTestTypeLoader(t))
+		if len(tc.expected) == 0 {
+			if err != nil {
+				t.Errorf("Unexpected error in checkTemplateData for %q: %s", tc.src, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Expected an error in checkTemplateData for %q but got none", tc.src)
+			continue
+		}
+		if got := strings.Split(err.Error(), "\n"); strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+			t.Errorf("Errors from checkTemplateData for %q were not correct.\nExpected:\n%s\nBut got:\n%s", tc.src, strings.Join(tc.expected, "\n"), err.Error())
+		}
+	}
+}
+
+func TestBuildChecksDataTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-typecheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "templates.go")
+	if err := Build("test_files/typed_templates", dest, "test_files/typed_partials", "", "main"); err != nil {
+		t.Fatalf("Unexpected error in Build: %s", err.Error())
+	}
+	// A typo in the partial should be reported with its filename and line.
+	partials := filepath.Join(dir, "partials")
+	writeTestFile(t, filepath.Join(partials, "todo.tmpl"), "<li>\n{{ .Titel }}\n</li>")
+	err = Build("test_files/typed_templates", dest, partials, "", "main")
+	if err == nil {
+		t.Fatal("Expected an error in Build for a template which accesses a field that does not exist but got none")
+	}
+	expected := filepath.Join(partials, "todo.tmpl") + ":2: can't evaluate field Titel in type *models.Todo"
+	if err.Error() != expected {
+		t.Errorf("Error from Build was not correct.\nExpected: %s\nBut got:  %s", expected, err.Error())
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/albrow/prtty"
+)
+
+// WatchInterval is how often Watch checks the source directories for
+// changes. A burst of changes (e.g. an editor saving several files at
+// once) only triggers a single rebuild, which happens once the files
+// have stopped changing for at least one interval.
+var WatchInterval = 250 * time.Millisecond
+
+// Watch is the function called when you run the watch sub-command
+// in the command line tool. It calls Build once with the given
+// arguments, and then calls it again whenever a template file in src,
+// partials, or layouts, or the IgnoreFile in one of those directories, is
+// added, changed, or removed. Errors from
+// Build are printed instead of returned, so Watch keeps running
+// after a template fails to compile. Watch only returns if it cannot
+// read the source directories. Any options are passed along to Build.
+func Watch(src, dest, partials, layouts, packageName string, options ...BuildOption) error {
+	return watch(src, dest, partials, layouts, packageName, options, nil)
+}
+
+// watch does the work for Watch. It returns as soon as stop is closed,
+// which is only used in tests.
+func watch(src, dest, partials, layouts, packageName string, options []BuildOption, stop <-chan struct{}) error {
+	dirs := sourceDirGroup{
+		templates: src,
+		partials:  partials,
+		layouts:   layouts,
+	}
+	opts, err := newBuildOptions(options)
+	if err != nil {
+		return err
+	}
+	rebuild := func() {
+		if err := Build(src, dest, partials, layouts, packageName, options...); err != nil {
+			prtty.Error.Println(err)
+		}
+	}
+	rebuild()
+	prtty.Info.Println("--> watching for changes...")
+	last, err := dirs.snapshot(opts.files)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(WatchInterval):
+		}
+		current, err := dirs.snapshot(opts.files)
+		if err != nil {
+			return err
+		}
+		if !current.equals(last) {
+			// Wait for the files to stop changing before rebuilding.
+			changed = true
+			last = current
+			continue
+		}
+		if changed {
+			changed = false
+			rebuild()
+		}
+	}
+}
+
+// fileStamp holds the information used to detect whether a
+// source file has changed.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// dirSnapshot maps the path of each source file to its fileStamp.
+type dirSnapshot map[string]fileStamp
+
+// snapshot returns a dirSnapshot for all the source files in dirs which
+// are collected by files, as well as the IgnoreFile in each directory.
+func (dirs sourceDirGroup) snapshot(files *fileMatcher) (dirSnapshot, error) {
+	snap := dirSnapshot{}
+	stamp := func(name, filename string) error {
+		info, err := os.Stat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// The file was removed after it was found, which the next
+				// snapshot will pick up.
+				return nil
+			}
+			return err
+		}
+		snap[filename] = fileStamp{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+		return nil
+	}
+	for _, dir := range []string{dirs.templates, dirs.partials, dirs.layouts} {
+		if dir == "" {
+			continue
+		}
+		if err := stamp(IgnoreFile, filepath.Join(dir, IgnoreFile)); err != nil {
+			return nil, err
+		}
+		if err := files.collect(dir, stamp); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// equals returns true iff snap and other contain exactly the same
+// files with the same stamps.
+func (snap dirSnapshot) equals(other dirSnapshot) bool {
+	if len(snap) != len(other) {
+		return false
+	}
+	for filename, stamp := range snap {
+		otherStamp, found := other[filename]
+		if !found || !stamp.modTime.Equal(otherStamp.modTime) || stamp.size != otherStamp.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Alex Browne.
+// All rights reserved. Use of this source code is
+// governed by the MIT license, which can be found
+// in the LICENSE file.
+
+package temple
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "temple-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "templates")
+	if err := os.Mkdir(src, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "templates.go")
+	if err := ioutil.WriteFile(filepath.Join(src, "home.tmpl"), []byte("home"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	oldInterval := WatchInterval
+	WatchInterval = 10 * time.Millisecond
+	defer func() {
+		WatchInterval = oldInterval
+	}()
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watch(src, dest, "", "", "main", nil, stop)
+	}()
+	expectFileContains(t, dest, `"home"`)
+	// A template that doesn't compile should not stop the watcher.
+	if err := ioutil.WriteFile(filepath.Join(src, "broken.tmpl"), []byte("{{ .Title "), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * WatchInterval)
+	if err := os.Remove(filepath.Join(src, "broken.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "about.tmpl"), []byte("about"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	expectFileContains(t, dest, `"about"`)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error in watch: %s", err.Error())
+	}
+}
+
+// expectFileContains waits for the file at filename to contain
+// substr and adds an error to t if it doesn't within a few seconds.
+func expectFileContains(t *testing.T, filename, substr string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		contents, err := ioutil.ReadFile(filename)
+		if err == nil && strings.Contains(string(contents), substr) {
+			return
+		}
+		time.Sleep(WatchInterval)
+	}
+	t.Errorf("Expected %s to contain %s but it did not.", filename, substr)
+}